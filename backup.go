@@ -0,0 +1,202 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	backupDir        = "backup"
+	backupTimeFormat = "20060102-150405"
+)
+
+var backup struct {
+	once sync.Once
+	err  error
+}
+
+// backupPatch copies Patch directory, or data directory of MV/MZ game, to a timestamped backup.
+// It only happens once per run just before the first file is modified
+func backupPatch() error {
+	if !backupEnabled {
+		return nil
+	}
+
+	backup.once.Do(func() {
+		dst := filepath.Join(patchDir, backupDir, time.Now().Format(backupTimeFormat))
+		src := sourceDir(patchDir)
+
+		backup.err = copyDir(filepath.Join(patchDir, src), filepath.Join(dst, src))
+		if backup.err != nil {
+			backup.err = errors.Wrap(backup.err, "failed to back up patch")
+			return
+		}
+
+		log.Infof("Original patch was backed up to %s", dst)
+	})
+
+	return backup.err
+}
+
+// listBackups returns names of backups in dir from oldest to newest
+func listBackups(dir string) ([]string, error) {
+	entries, err := ioutil.ReadDir(filepath.Join(dir, backupDir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var names []string
+
+	for _, e := range entries {
+		if _, err := time.Parse(backupTimeFormat, e.Name()); err == nil && e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// restoreCommand replaces Patch directory with one of the backups
+func restoreCommand(args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+
+	var name string
+	var list bool
+
+	fs.StringVar(&name, "backup", "", "Name of backup to restore, latest one is used by default")
+	fs.BoolVar(&list, "list", false, "List available backups instead of restoring")
+
+	fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		return fmt.Errorf("restore command requires patch directory as argument")
+	}
+
+	dir := fs.Arg(0)
+
+	backups, err := listBackups(dir)
+	if err != nil {
+		return err
+	}
+
+	if list {
+		for _, b := range backups {
+			fmt.Println(b)
+		}
+
+		return nil
+	}
+
+	if len(backups) < 1 {
+		return fmt.Errorf("no backups found in %s", filepath.Join(dir, backupDir))
+	}
+
+	if len(name) < 1 {
+		name = backups[len(backups)-1]
+	}
+
+	rel, err := backupSource(filepath.Join(dir, backupDir, name))
+	if err != nil {
+		return errors.Wrapf(err, "backup %q not found", name)
+	}
+
+	src := filepath.Join(dir, backupDir, name, rel)
+	patch := filepath.Join(dir, rel)
+	tmp := patch + ".restore"
+	old := patch + ".old"
+
+	// Copy first so a failure leaves current patch in place
+	os.RemoveAll(tmp)
+
+	if err := copyDir(src, tmp); err != nil {
+		os.RemoveAll(tmp)
+		return errors.Wrap(err, "failed to copy backup")
+	}
+
+	if err := os.Rename(patch, old); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+
+	if err := os.Rename(tmp, patch); err != nil {
+		os.Rename(old, patch)
+		return err
+	}
+
+	if err := os.RemoveAll(old); err != nil {
+		return err
+	}
+
+	fmt.Printf("Restored patch from backup %s\n", name)
+
+	return nil
+}
+
+// backupSource returns which directory backup contains
+func backupSource(dir string) (string, error) {
+	var err error
+
+	for _, rel := range []string{"Patch", "data", filepath.Join("www", "data")} {
+		if _, err = os.Stat(filepath.Join(dir, rel)); err == nil {
+			return rel, nil
+		}
+	}
+
+	return "", err
+}
+
+// copyDir copies every file in src to dst, keeping directory structure
+func copyDir(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dst, rel)
+
+		if info.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+
+		return copyFile(path, target, info.Mode().Perm())
+	})
+}
+
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestBackupRestore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "backup")
+	check(err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "Patch", "Map001.txt")
+
+	err = os.MkdirAll(filepath.Dir(file), 0755)
+	check(err)
+
+	err = ioutil.WriteFile(file, []byte("original"), 0644)
+	check(err)
+
+	defer func(dir string, enabled bool) {
+		patchDir, backupEnabled = dir, enabled
+	}(patchDir, backupEnabled)
+
+	patchDir = dir
+	backupEnabled = true
+	backup.once = sync.Once{}
+
+	err = backupPatch()
+	check(err)
+
+	err = ioutil.WriteFile(file, []byte("modified"), 0644)
+	check(err)
+
+	// Only the first modification is backed up
+	err = backupPatch()
+	check(err)
+
+	backups, err := listBackups(dir)
+	check(err)
+
+	if len(backups) != 1 {
+		t.Fatalf("expected 1 backup, found %d", len(backups))
+	}
+
+	err = restoreCommand([]string{dir})
+	check(err)
+
+	data, err := ioutil.ReadFile(file)
+	check(err)
+
+	if string(data) != "original" {
+		t.Errorf("restored file contains %q", data)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "Patch.old")); !os.IsNotExist(err) {
+		t.Error("previous patch directory was left behind")
+	}
+}
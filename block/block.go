@@ -1,26 +1,22 @@
 package block
 
 import (
+	"context"
+	"fmt"
+
 	"gitgud.io/softashell/rpgmaker-patch-translator/lex"
+	"gitgud.io/softashell/rpgmaker-patch-translator/memory"
+	"gitgud.io/softashell/rpgmaker-patch-translator/patch"
 	"gitgud.io/softashell/rpgmaker-patch-translator/statictl"
 	"gitgud.io/softashell/rpgmaker-patch-translator/text"
+	"gitgud.io/softashell/rpgmaker-patch-translator/translate"
 	"github.com/davecgh/go-spew/spew"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
-type PatchBlock struct {
-	Original     string
-	Translations []TranslationBlock
-}
-
-type TranslationBlock struct {
-	Contexts   []string
-	Text       string
-	Touched    bool
-	Translated bool
-}
-
 var stl *statictl.Db
+var mem *memory.Memory
 
 // Set the nasty global variables
 func Init() {
@@ -29,23 +25,47 @@ func Init() {
 	}
 }
 
-func ParseBlock(block PatchBlock) PatchBlock {
+// SetGlossary makes pre and post translation keep glossary terms translated the same way
+func SetGlossary(g statictl.Glossary) {
+	stl.SetGlossary(g)
+}
+
+// SetMemory enables reuse of existing translations before asking translation service
+func SetMemory(m *memory.Memory) {
+	mem = m
+}
+
+// ParseBlock translates every untranslated part of the block, translations
+// that failed are left untranslated and returned error describes what went wrong.
+// If ctx is done before block is finished it's returned unchanged
+func ParseBlock(ctx context.Context, block patch.PatchBlock) (patch.PatchBlock, error) {
+	if err := ctx.Err(); err != nil {
+		return block, err
+	}
+
 	if !text.ShouldTranslate(block.Original) {
-		return block
+		return block, nil
 	}
 
+	original := block
+	original.Translations = append([]patch.TranslationBlock(nil), block.Translations...)
+
 	sourceText, err := stl.RunPreTranslation(block.Original)
 	if err != nil {
 		log.Errorf("failed to apply pre translation: %v", err)
 	}
 
 	block = ParseBlockLocalTL(block, sourceText)
-	block = ParseBlockRemoteTL(block, sourceText)
 
-	return block
+	block, err = ParseBlockRemoteTL(ctx, block, sourceText)
+	if ctx.Err() != nil {
+		return original, ctx.Err()
+	}
+
+	return block, err
 }
 
-func ParseBlockLocalTL(block PatchBlock, sourceText string) PatchBlock {
+func ParseBlockLocalTL(block patch.PatchBlock, sourceText string) patch.PatchBlock {
 	var untranslated []string
 
 	for i, t := range block.Translations {
@@ -61,6 +81,8 @@ func ParseBlockLocalTL(block PatchBlock, sourceText string) PatchBlock {
 
 		untranslated = append(untranslated, untranslatedContexts...)
 
+		blocks[0].Directives = t.Directives
+
 		// Replace current
 		if len(blocks) == 1 {
 			block.Translations[i] = blocks[0]
@@ -79,7 +101,7 @@ func ParseBlockLocalTL(block PatchBlock, sourceText string) PatchBlock {
 
 	// Leftovers
 	if len(untranslated) > 0 {
-		block.Translations = append(block.Translations, TranslationBlock{
+		block.Translations = append(block.Translations, patch.TranslationBlock{
 			Text:       "",
 			Contexts:   untranslated,
 			Translated: false,
@@ -91,8 +113,8 @@ func ParseBlockLocalTL(block PatchBlock, sourceText string) PatchBlock {
 	return block
 }
 
-func ParseBlockRemoteTL(block PatchBlock, sourceText string) PatchBlock {
-	var err error
+func ParseBlockRemoteTL(ctx context.Context, block patch.PatchBlock, sourceText string) (patch.PatchBlock, error) {
+	var err, tlErr error
 	var items []lex.Item
 	var untranslated []string
 	var translated, parsed bool
@@ -103,35 +125,49 @@ func ParseBlockRemoteTL(block PatchBlock, sourceText string) PatchBlock {
 		}
 
 		good, bad := getTranslatableContexts(t, sourceText)
-		untranslated = append(untranslated, bad...)
 
 		if len(good) < 1 {
+			untranslated = append(untranslated, bad...)
 			continue
 		}
 
-		if !parsed {
-			items, err = lex.ParseText(sourceText)
+		if match, ok := lookupMemory(block.Original); ok {
+			// Remembered translation already has its line breaks, it's not touched so they're kept
+			t.Text = match.Translation
+
+			if match.Similarity < 1 {
+				translate.AddFlag(block.Original, t.Text, fmt.Sprintf("translation of similar text reused from memory (%.0f%%): %q", match.Similarity*100, match.Original))
+			}
+		} else {
+			if !parsed {
+				// Glossary terms are hidden from translation service so their translation is always the same
+				items, err = lex.ParseText(stl.ProtectTerms(sourceText))
+				if err != nil {
+					return block, nil
+				}
+
+				parsed = true
+			}
+
+			t.Text, err = lex.TranslateItems(ctx, items, block.Preceding)
 			if err != nil {
-				return block
+				// Translation service gave up, leave this and remaining blocks untranslated
+				tlErr = errors.Wrap(err, "failed to translate items")
+				break
 			}
 
-			parsed = true
-		}
+			t.Text, err = stl.RunPostTranslation(t.Text)
+			if err != nil {
+				log.Errorf("failed to apply post translation: %v", err)
+			}
 
-		t.Text, err = lex.TranslateItems(items)
-		if err != nil {
-			// This should only fail if translation service is down
-			log.Fatalf("failed to translate items: %v", err)
+			t.Touched = true
 		}
 
-		t.Text, err = stl.RunPostTranslation(t.Text)
-		if err != nil {
-			log.Errorf("failed to apply post translation: %v", err)
-		}
+		untranslated = append(untranslated, bad...)
 
 		t.Contexts = good
 		t.Translated = true
-		t.Touched = true
 
 		block.Translations[i] = t
 
@@ -141,7 +177,7 @@ func ParseBlockRemoteTL(block PatchBlock, sourceText string) PatchBlock {
 	}
 
 	if translated && len(untranslated) > 0 {
-		block.Translations = append(block.Translations, TranslationBlock{
+		block.Translations = append(block.Translations, patch.TranslationBlock{
 			Text:       "",
 			Contexts:   untranslated,
 			Translated: false,
@@ -150,12 +186,29 @@ func ParseBlockRemoteTL(block PatchBlock, sourceText string) PatchBlock {
 		log.Infof("Mixed block in comfy\n %s", spew.Sdump(block))
 	}
 
-	return block
+	return block, tlErr
+}
+
+func lookupMemory(original string) (memory.Match, bool) {
+	if mem == nil {
+		return memory.Match{}, false
+	}
+
+	match, ok := mem.Lookup(original)
+	if !ok {
+		return match, false
+	}
+
+	if match.Similarity < 1 {
+		log.Infof("Reusing translation of similar text (%.0f%%)\n%q\n%q => %q", match.Similarity*100, original, match.Original, match.Translation)
+	}
+
+	return match, true
 }
 
-func TranslateBlockStatic(b TranslationBlock, originalText string) ([]TranslationBlock, []string, error) {
+func TranslateBlockStatic(b patch.TranslationBlock, originalText string) ([]patch.TranslationBlock, []string, error) {
 	tlTypes := GetContextTypes(b.Contexts)
-	blocks := []TranslationBlock{}
+	blocks := []patch.TranslationBlock{}
 	untranslated := []string{}
 
 	for t, c := range tlTypes {
@@ -165,7 +218,7 @@ func TranslateBlockStatic(b TranslationBlock, originalText string) ([]Translatio
 			continue
 		}
 
-		block := TranslationBlock{
+		block := patch.TranslationBlock{
 			Text:       text,
 			Contexts:   c,
 			Touched:    true,
@@ -0,0 +1,57 @@
+package block
+
+import (
+	"context"
+	"testing"
+
+	"gitgud.io/softashell/rpgmaker-patch-translator/engine"
+	"gitgud.io/softashell/rpgmaker-patch-translator/memory"
+	"gitgud.io/softashell/rpgmaker-patch-translator/patch"
+	"gitgud.io/softashell/rpgmaker-patch-translator/translate"
+)
+
+func TestMemoryReuseFlagged(t *testing.T) {
+	m := memory.New(0.5)
+	m.Add("勇者が来た！", "The hero came!")
+
+	SetMemory(m)
+	defer SetMemory(nil)
+
+	defer engine.Set(engine.Get())
+	engine.Set(engine.RPGMVX)
+
+	tests := []struct {
+		original string
+		flagged  bool
+	}{
+		{"勇者が来た！", false},
+		{"勇者が来た？", true},
+	}
+
+	for _, tt := range tests {
+		b := patch.PatchBlock{
+			Original:     tt.original,
+			Translations: []patch.TranslationBlock{{Contexts: []string{": Map001/1/1/Dialogue/0"}}},
+		}
+
+		before := len(translate.Flags())
+
+		b, err := ParseBlockRemoteTL(context.Background(), b, tt.original)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := b.Translations[0].Text; got != "The hero came!" {
+			t.Errorf("%q translated as %q, want %q", tt.original, got, "The hero came!")
+		}
+
+		// Line breaks of remembered translation are kept
+		if b.Translations[0].Touched {
+			t.Errorf("%q reused from memory is marked as touched", tt.original)
+		}
+
+		if flagged := len(translate.Flags()) > before; flagged != tt.flagged {
+			t.Errorf("%q flagged = %v, want %v", tt.original, flagged, tt.flagged)
+		}
+	}
+}
@@ -6,9 +6,10 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"gitgud.io/softashell/rpgmaker-patch-translator/engine"
+	"gitgud.io/softashell/rpgmaker-patch-translator/patch"
 )
 
-func getTranslatableContexts(block TranslationBlock, text string) ([]string, []string) {
+func getTranslatableContexts(block patch.TranslationBlock, text string) ([]string, []string) {
 	var good, bad []string
 
 	for _, c := range block.Contexts {
@@ -101,7 +102,7 @@ func shouldTranslateContextWolf(c, text string) bool {
 
 func shouldTranslateContext(c, text string) bool {
 	switch engine.Get() {
-	case engine.RPGMVX:
+	case engine.RPGMVX, engine.MV:
 		return shouldTranslateContextVX(c, text)
 	case engine.Wolf:
 		return shouldTranslateContextWolf(c, text)
@@ -111,19 +112,3 @@ func shouldTranslateContext(c, text string) bool {
 
 	return false
 }
-
-func ShouldBreakLines(contexts []string) bool {
-	for _, c := range contexts {
-		if engine.Is(engine.RPGMVX) {
-			if strings.Contains(c, "GameINI/Title") || strings.Contains(c, "System/game_title/") {
-				return false
-			}
-		} else if engine.Is(engine.Wolf) {
-			if strings.HasPrefix(c, " GAMEDAT:") {
-				return false
-			}
-		}
-	}
-
-	return true
-}
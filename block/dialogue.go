@@ -0,0 +1,82 @@
+package block
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"gitgud.io/softashell/rpgmaker-patch-translator/patch"
+)
+
+// DialoguePosition splits dialogue context like ": Map040/7/40/Dialogue/9" into
+// event page it belongs to (": Map040/7/40") and command index (9)
+func DialoguePosition(c string) (string, int, bool) {
+	i := strings.LastIndex(c, "/Dialogue/")
+	if i == -1 {
+		return "", 0, false
+	}
+
+	index, err := strconv.Atoi(strings.TrimSpace(c[i+len("/Dialogue/"):]))
+	if err != nil {
+		return "", 0, false
+	}
+
+	return c[:i], index, true
+}
+
+// AddDialogueHistory fills Preceding with up to n lines of dialogue that come
+// before each block on the same event page, so they can be used as translation context
+func AddDialogueHistory(blocks []patch.PatchBlock, n int) {
+	if n < 1 {
+		return
+	}
+
+	type line struct {
+		index int
+		block int
+	}
+
+	pages := make(map[string][]line)
+
+	for i, b := range blocks {
+		page, index, ok := blockDialoguePosition(b)
+		if !ok {
+			continue
+		}
+
+		pages[page] = append(pages[page], line{index, i})
+	}
+
+	for _, lines := range pages {
+		sort.Slice(lines, func(a, b int) bool {
+			return lines[a].index < lines[b].index
+		})
+
+		for i, l := range lines {
+			start := i - n
+			if start < 0 {
+				start = 0
+			}
+
+			var preceding []string
+			for _, p := range lines[start:i] {
+				preceding = append(preceding, strings.TrimSpace(blocks[p.block].Original))
+			}
+
+			blocks[l.block].Preceding = preceding
+		}
+	}
+}
+
+// blockDialoguePosition returns position of the first dialogue context in block
+func blockDialoguePosition(b patch.PatchBlock) (string, int, bool) {
+	for _, t := range b.Translations {
+		for _, c := range t.Contexts {
+			if page, index, ok := DialoguePosition(c); ok {
+				return page, index, true
+			}
+		}
+	}
+
+	return "", 0, false
+}
@@ -0,0 +1,65 @@
+package block
+
+import (
+	"reflect"
+	"testing"
+
+	"gitgud.io/softashell/rpgmaker-patch-translator/patch"
+)
+
+func TestDialoguePosition(t *testing.T) {
+	tests := []struct {
+		c     string
+		page  string
+		index int
+		ok    bool
+	}{
+		{": Map040/7/40/Dialogue/9", ": Map040/7/40", 9, true},
+		{": Commonevents/10/26/Dialogue/0", ": Commonevents/10/26", 0, true},
+		{": Commonevents/10/26/Choice/0", "", 0, false},
+		{": Actors/1/name/", "", 0, false},
+		{": Map040/7/40/Dialogue/x", "", 0, false},
+	}
+
+	for _, tt := range tests {
+		page, index, ok := DialoguePosition(tt.c)
+		if page != tt.page || index != tt.index || ok != tt.ok {
+			t.Errorf("DialoguePosition(%q) = %q, %d, %v, want %q, %d, %v", tt.c, page, index, ok, tt.page, tt.index, tt.ok)
+		}
+	}
+}
+
+func TestAddDialogueHistory(t *testing.T) {
+	newBlock := func(original string, contexts ...string) patch.PatchBlock {
+		return patch.PatchBlock{
+			Original:     original,
+			Translations: []patch.TranslationBlock{{Contexts: contexts}},
+		}
+	}
+
+	blocks := []patch.PatchBlock{
+		newBlock("三\n", ": Map001/1/1/Dialogue/5"),
+		newBlock("一\n", ": Map001/1/1/Dialogue/1"),
+		newBlock("別\n", ": Map001/2/1/Dialogue/2"),
+		newBlock("二\n", ": Map001/1/1/Dialogue/3"),
+		newBlock("はい\n", ": Map001/1/1/Choice/0"),
+		newBlock("四\n", ": Map001/1/1/Dialogue/8"),
+	}
+
+	AddDialogueHistory(blocks, 2)
+
+	want := [][]string{
+		{"一", "二"},
+		nil,
+		nil,
+		{"一"},
+		nil,
+		{"二", "三"},
+	}
+
+	for i, b := range blocks {
+		if !reflect.DeepEqual(b.Preceding, want[i]) {
+			t.Errorf("block %q has history %q, want %q", b.Original, b.Preceding, want[i])
+		}
+	}
+}
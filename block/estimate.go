@@ -0,0 +1,90 @@
+package block
+
+import (
+	"gitgud.io/softashell/rpgmaker-patch-translator/lex"
+	"gitgud.io/softashell/rpgmaker-patch-translator/patch"
+	"gitgud.io/softashell/rpgmaker-patch-translator/statictl"
+	"gitgud.io/softashell/rpgmaker-patch-translator/text"
+	"golang.org/x/text/width"
+)
+
+// Fragment is a piece of text that would be sent to translation service
+type Fragment struct {
+	Type statictl.TranslationType
+	Text string
+}
+
+// EstimateBlock goes through the same steps as ParseBlock and returns text that
+// would be sent to translation service without translating anything
+func EstimateBlock(block patch.PatchBlock) ([]Fragment, error) {
+	if !text.ShouldTranslate(block.Original) {
+		return nil, nil
+	}
+
+	sourceText, err := stl.RunPreTranslation(block.Original)
+	if err != nil {
+		return nil, err
+	}
+
+	// Static translation modifies translations in place
+	block.Translations = append([]patch.TranslationBlock(nil), block.Translations...)
+	block = ParseBlockLocalTL(block, sourceText)
+
+	var fragments []Fragment
+	var items []lex.Item
+
+	for _, t := range block.Translations {
+		if t.Translated {
+			continue
+		}
+
+		good, _ := getTranslatableContexts(t, sourceText)
+		if len(good) < 1 {
+			continue
+		}
+
+		if _, ok := lookupMemory(block.Original); ok {
+			continue
+		}
+
+		if items == nil {
+			items, err = lex.ParseText(stl.ProtectTerms(sourceText))
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		typ := fragmentType(good)
+
+		for _, item := range items {
+			val := item.Val
+
+			switch item.Typ {
+			case lex.ItemText:
+			case lex.ItemNumber:
+				val = width.Narrow.String(val)
+			default:
+				continue
+			}
+
+			if text.ShouldTranslate(val) {
+				fragments = append(fragments, Fragment{Type: typ, Text: val})
+			}
+		}
+	}
+
+	return fragments, nil
+}
+
+// fragmentType returns the most generic translation type of contexts
+func fragmentType(contexts []string) statictl.TranslationType {
+	typ := statictl.TranslationType(-1)
+
+	for t := range GetContextTypes(contexts) {
+		if typ < 0 || t < typ {
+			typ = t
+		}
+	}
+
+	return typ
+}
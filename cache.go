@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"gitgud.io/softashell/rpgmaker-patch-translator/translate"
+	"github.com/pkg/errors"
+)
+
+// cacheCommand handles "cache prune" and "cache export"
+func cacheCommand(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("cache command requires prune or export as argument")
+	}
+
+	if len(translateOptions.CacheFile) < 1 {
+		return fmt.Errorf("cache is disabled")
+	}
+
+	fs := flag.NewFlagSet("cache "+args[0], flag.ExitOnError)
+
+	var olderThan time.Duration
+	var backendName, from, to, output string
+	var all bool
+
+	fs.StringVar(&backendName, "backend", "", "Only entries from this backend")
+	fs.StringVar(&from, "from", "", "Only entries with this source language")
+	fs.StringVar(&to, "to", "", "Only entries with this target language")
+
+	switch args[0] {
+	case "prune":
+		fs.DurationVar(&olderThan, "olderthan", 0, "Only entries created before this long ago")
+		fs.BoolVar(&all, "all", false, "Remove every entry when no other filter is given")
+	case "export":
+		fs.StringVar(&output, "o", "", "Write entries to file instead of stdout")
+	default:
+		return fmt.Errorf("unknown cache command %q", args[0])
+	}
+
+	fs.Parse(args[1:])
+
+	noFilter := len(backendName) < 1 && len(from) < 1 && len(to) < 1 && olderThan <= 0
+
+	if args[0] == "prune" && noFilter && !all {
+		return fmt.Errorf("cache prune requires -backend, -from, -to or -olderthan filter, use -all to remove every entry")
+	}
+
+	filter := func(e translate.CacheEntry) bool {
+		if len(backendName) > 0 && e.Backend != backendName {
+			return false
+		}
+
+		if len(from) > 0 && e.From != from {
+			return false
+		}
+
+		if len(to) > 0 && e.To != to {
+			return false
+		}
+
+		if olderThan > 0 && time.Since(e.Created) < olderThan {
+			return false
+		}
+
+		return true
+	}
+
+	c, err := translate.OpenCache(translateOptions.CacheFile)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	switch args[0] {
+	case "prune":
+		before := c.Stats().Entries
+
+		removed, err := c.Prune(filter)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Removed %d of %d entries from %s\n", removed, before, translateOptions.CacheFile)
+	case "export":
+		entries := c.Entries(filter)
+
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].Text < entries[j].Text
+		})
+
+		var w io.Writer = os.Stdout
+
+		if len(output) > 0 {
+			f, err := os.Create(output)
+			if err != nil {
+				return errors.Wrap(err, "failed to create export file")
+			}
+			defer f.Close()
+
+			w = f
+		}
+
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+
+		if err := enc.Encode(entries); err != nil {
+			return errors.Wrap(err, "failed to export cache")
+		}
+	}
+
+	return nil
+}
+
+func printCacheStats() {
+	stats, ok := translate.Stats()
+	if !ok {
+		return
+	}
+
+	fmt.Printf("Cache: %d hits, %d misses, %d new entries, %d total entries\n", stats.Hits, stats.Misses, stats.Writes, stats.Entries)
+}
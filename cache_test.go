@@ -0,0 +1,61 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitgud.io/softashell/rpgmaker-patch-translator/translate"
+)
+
+func TestCachePrune(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	defer func(file string) { translateOptions.CacheFile = file }(translateOptions.CacheFile)
+	translateOptions.CacheFile = filepath.Join(dir, "cache.jsonl")
+
+	c, err := translate.OpenCache(translateOptions.CacheFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.Put("comfy", "ja", "en", "", "テスト", "Test")
+	c.Put("rest", "ja", "en", "", "テスト", "Exam")
+	c.Close()
+
+	entries := func() int {
+		c, err := translate.OpenCache(translateOptions.CacheFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+
+		return c.Stats().Entries
+	}
+
+	tests := []struct {
+		args    []string
+		wantErr bool
+		want    int
+	}{
+		{[]string{"prune"}, true, 2},
+		{[]string{"prune", "-backend", "rest"}, false, 1},
+		{[]string{"prune", "-all"}, false, 0},
+	}
+
+	for _, tt := range tests {
+		err := cacheCommand(tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("cacheCommand(%q) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+		}
+
+		if got := entries(); got != tt.want {
+			t.Errorf("cacheCommand(%q) left %d entries, want %d", tt.args, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+
+	"gitgud.io/softashell/rpgmaker-patch-translator/translate"
+	"github.com/pkg/errors"
+)
+
+// writeComparisons saves every translation candidate from compare mode
+func writeComparisons(file string) error {
+	var out bytes.Buffer
+
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(translate.Comparisons()); err != nil {
+		return err
+	}
+
+	return errors.Wrapf(ioutil.WriteFile(file, out.Bytes(), 0644), "failed to write %q", file)
+}
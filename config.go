@@ -0,0 +1,31 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	"gitgud.io/softashell/rpgmaker-patch-translator/translate"
+	"github.com/hjson/hjson-go"
+	"github.com/pkg/errors"
+)
+
+// loadConfig reads translation settings from hjson file, missing keys are left as they were
+func loadConfig(file string, opts *translate.Options) error {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read config file %q", file)
+	}
+
+	var dat map[string]interface{}
+	if err := hjson.Unmarshal(data, &dat); err != nil {
+		return errors.Wrapf(err, "failed to parse config file %q", file)
+	}
+
+	// convert to JSON
+	b, err := json.Marshal(dat)
+	if err != nil {
+		return err
+	}
+
+	return errors.Wrapf(json.Unmarshal(b, opts), "invalid config file %q", file)
+}
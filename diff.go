@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"gitgud.io/softashell/rpgmaker-patch-translator/patch"
+)
+
+// Diffs of files changed in dry run, they are written once every file is done so they don't mix with progress bars
+var diffs = struct {
+	sync.Mutex
+	files map[string][]byte
+}{files: make(map[string][]byte)}
+
+// addDiff remembers what would change in file instead of writing it
+func addDiff(old, new patch.File) error {
+	name := old.Path
+	if rel, err := filepath.Rel(patchDir, old.Path); err == nil {
+		name = filepath.ToSlash(rel)
+	}
+
+	var buf bytes.Buffer
+
+	if err := patch.Diff(&buf, name, old, new, breakLines); err != nil {
+		return err
+	}
+
+	if buf.Len() < 1 {
+		return nil
+	}
+
+	diffs.Lock()
+	diffs.files[name] = buf.Bytes()
+	diffs.Unlock()
+
+	return nil
+}
+
+// writeDiffs writes diffs of every changed file to stdout or diffFile
+func writeDiffs() error {
+	if len(diffFile) < 1 {
+		return printDiffs(os.Stdout)
+	}
+
+	f, err := os.Create(diffFile)
+	if err != nil {
+		return err
+	}
+
+	if err := printDiffs(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// printDiffs writes diffs sorted by file name
+func printDiffs(out io.Writer) error {
+	diffs.Lock()
+	defer diffs.Unlock()
+
+	names := make([]string, 0, len(diffs.files))
+	for name := range diffs.files {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		if _, err := out.Write(diffs.files[name]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
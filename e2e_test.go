@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitgud.io/softashell/rpgmaker-patch-translator/block"
+	"gitgud.io/softashell/rpgmaker-patch-translator/engine"
+	"gitgud.io/softashell/rpgmaker-patch-translator/patch"
+	"gitgud.io/softashell/rpgmaker-patch-translator/translate"
+	"github.com/vbauerster/mpb"
+)
+
+// Cancels dispatch of new blocks once translation service is used
+var stopDispatch context.CancelFunc
+
+type stoppingTranslator struct{}
+
+func (stoppingTranslator) Translate(ctx context.Context, req translate.Request) (translate.Response, error) {
+	stopDispatch()
+
+	// Give file worker time to notice it should stop
+	time.Sleep(50 * time.Millisecond)
+
+	return translate.Response{TranslationText: "translated"}, nil
+}
+
+func (stoppingTranslator) Close() error {
+	return nil
+}
+
+func init() {
+	translate.Register("test-stop", func(opts translate.Options) (translate.Translator, error) {
+		return stoppingTranslator{}, nil
+	})
+}
+
+var wd, _ = os.Getwd()
+
+// tempPatchDir creates temporary directory and makes it working directory since
+// static translation databases are created there
+func tempPatchDir(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "e2e")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	return dir, func() {
+		os.Chdir(wd)
+		os.RemoveAll(dir)
+	}
+}
+
+// TestOfflinePipeline runs the whole pipeline with reverse backend so output can be checked without translation service
+func TestOfflinePipeline(t *testing.T) {
+	dir, cleanup := tempPatchDir(t)
+	defer cleanup()
+
+	src := filepath.Join(wd, "testdata", "e2e")
+
+	copyFile := func(name string) {
+		data, err := ioutil.ReadFile(filepath.Join(src, name))
+		check(err)
+
+		err = os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755)
+		check(err)
+
+		err = ioutil.WriteFile(filepath.Join(dir, name), data, 0644)
+		check(err)
+	}
+
+	copyFile("RPGMKTRANSPATCH")
+	copyFile(filepath.Join("Patch", "Map001.txt"))
+
+	err := checkPatchVersion(dir)
+	check(err)
+
+	lineLength = 42
+	lineTolerance = 5
+
+	err = translate.Init(translate.Options{Backend: "reverse"})
+	check(err)
+	defer translate.Close()
+
+	block.Init()
+
+	p := mpb.New(mpb.WithOutput(ioutil.Discard))
+
+	file := filepath.Join(dir, "Patch", "Map001.txt")
+
+	before, err := ioutil.ReadFile(file)
+	check(err)
+
+	// Cancelled run leaves the file untouched
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := processFile(ctx, ctx, p, file); err != context.Canceled {
+		t.Errorf("processFile() error = %v, want %v", err, context.Canceled)
+	}
+
+	after, err := ioutil.ReadFile(file)
+	check(err)
+
+	if !bytes.Equal(before, after) {
+		t.Error("cancelled run modified patch file")
+	}
+
+	err = processFile(context.Background(), context.Background(), p, file)
+	check(err)
+
+	pf, err := patch.ReadFile(file, strictPatch)
+	check(err)
+
+	want := []struct {
+		text       string
+		translated bool
+	}{
+		{"はちにんこ\n", true},
+		{"\\C[2] 者勇 \\C[0] た来が\n", true},
+		{"Yes\n", true},
+		{"", false},
+		{"", false},
+	}
+
+	if len(pf.Blocks) != len(want) {
+		t.Fatalf("expected %d blocks got %d", len(want), len(pf.Blocks))
+	}
+
+	for i, w := range want {
+		tl := pf.Blocks[i].Translations[0]
+
+		if tl.Translated != w.translated || tl.Text != w.text {
+			t.Errorf("block %d expected %q (translated: %v) got %q (translated: %v)", i, w.text, w.translated, tl.Text, tl.Translated)
+		}
+	}
+}
+
+// TestStoppedPipeline checks that stopped file is saved with finished blocks and the rest stays untranslated
+func TestStoppedPipeline(t *testing.T) {
+	dir, cleanup := tempPatchDir(t)
+	defer cleanup()
+
+	file := filepath.Join(dir, "Map001.txt")
+
+	pf := patch.File{
+		Path:    file,
+		Version: "RPGMAKER TRANS PATCH FILE VERSION 3.2",
+		Format:  patch.DefaultFormat,
+	}
+
+	for i := 0; i < 10; i++ {
+		pf.Blocks = append(pf.Blocks, patch.PatchBlock{
+			Original: fmt.Sprintf("テスト%d\n", i),
+			Translations: []patch.TranslationBlock{
+				{Contexts: []string{fmt.Sprintf(": Map001/1/1/Dialogue/%d", i)}},
+			},
+		})
+	}
+
+	err := patch.WriteFile(pf, nil)
+	check(err)
+
+	threads := cBlockThreads
+	defer func() { cBlockThreads = threads }()
+
+	cBlockThreads = 1
+
+	err = translate.Init(translate.Options{Backend: "test-stop"})
+	check(err)
+	defer translate.Close()
+
+	block.Init()
+
+	p := mpb.New(mpb.WithOutput(ioutil.Discard))
+
+	var stop context.Context
+	stop, stopDispatch = context.WithCancel(context.Background())
+
+	if err := processFile(context.Background(), stop, p, file); err != context.Canceled {
+		t.Errorf("processFile() error = %v, want %v", err, context.Canceled)
+	}
+
+	pf, err = patch.ReadFile(file, strictPatch)
+	check(err)
+
+	if len(pf.Blocks) != 10 {
+		t.Fatalf("expected 10 blocks got %d", len(pf.Blocks))
+	}
+
+	if !pf.Blocks[0].Translations[0].Translated {
+		t.Error("block in progress wasn't saved")
+	}
+
+	if pf.Blocks[9].Translations[0].Translated {
+		t.Error("block that wasn't started was translated")
+	}
+}
+
+// TestMVPipeline translates MV data file with reverse backend
+func TestMVPipeline(t *testing.T) {
+	dir, cleanup := tempPatchDir(t)
+	defer cleanup()
+
+	defer engine.Set(engine.Get())
+
+	src := filepath.Join(wd, "testdata", "mv")
+
+	err := copyDir(src, dir)
+	check(err)
+
+	err = checkPatchVersion(dir)
+	check(err)
+
+	if !engine.Is(engine.MV) {
+		t.Fatalf("engine = %v, want MV", engine.Get())
+	}
+
+	files := sourceFiles(dir)
+	if len(files) != 3 {
+		t.Errorf("expected 3 data files, got %q", files)
+	}
+
+	lineLength = 42
+	lineTolerance = 5
+
+	err = translate.Init(translate.Options{Backend: "reverse"})
+	check(err)
+	defer translate.Close()
+
+	block.Init()
+
+	p := mpb.New(mpb.WithOutput(ioutil.Discard))
+
+	file := filepath.Join(dir, "data", "Map001.json")
+
+	err = processFile(context.Background(), context.Background(), p, file)
+	check(err)
+
+	data, err := ioutil.ReadFile(file)
+	check(err)
+
+	for _, s := range []string{`"displayName":"村のりま始"`, `"parameters":["はちにんこ"]`, `"parameters":["\\C[2] 者勇 \\C[0] た来が"]`, `[["いは","えいい"],1,0,2,0]`} {
+		if !bytes.Contains(data, []byte(s)) {
+			t.Errorf("translated file doesn't contain %s:\n%s", s, data)
+		}
+	}
+}
@@ -6,6 +6,7 @@ const (
 	None EngineType = iota
 	RPGMVX
 	Wolf
+	MV // RPG Maker MV and MZ data/*.json files without a patch
 )
 
 var engine EngineType
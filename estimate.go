@@ -0,0 +1,186 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+	"unicode/utf8"
+
+	"gitgud.io/softashell/rpgmaker-patch-translator/block"
+	"gitgud.io/softashell/rpgmaker-patch-translator/statictl"
+	"gitgud.io/softashell/rpgmaker-patch-translator/text"
+	log "github.com/sirupsen/logrus"
+)
+
+// estimateStats counts text that would be sent to translation service
+type estimateStats struct {
+	name string
+
+	fragments        int
+	characters       int
+	uniqueFragments  int
+	uniqueCharacters int
+
+	seen map[string]bool
+}
+
+func newEstimateStats(name string) *estimateStats {
+	return &estimateStats{
+		name: name,
+		seen: make(map[string]bool),
+	}
+}
+
+func (s *estimateStats) add(str string) {
+	n := utf8.RuneCountInString(str)
+
+	s.fragments++
+	s.characters += n
+
+	if !s.seen[str] {
+		s.seen[str] = true
+		s.uniqueFragments++
+		s.uniqueCharacters += n
+	}
+}
+
+// estimate collects fragments of the whole patch
+type estimate struct {
+	total *estimateStats
+	files []*estimateStats
+	types map[statictl.TranslationType]*estimateStats
+}
+
+func newEstimate() *estimate {
+	return &estimate{
+		total: newEstimateStats("Total"),
+		types: make(map[statictl.TranslationType]*estimateStats),
+	}
+}
+
+// addFile runs every block of patch file through the pipeline without translating it
+func (e *estimate) addFile(file string) error {
+	pf, err := readSource(file)
+	if err != nil {
+		return err
+	}
+
+	stats := newEstimateStats(file)
+	if rel, err := filepath.Rel(patchDir, file); err == nil {
+		stats.name = rel
+	}
+
+	for _, b := range pf.Blocks {
+		fragments, err := block.EstimateBlock(b)
+		if err != nil {
+			log.Errorf("failed to estimate block %q: %v", b.Original, err)
+			continue
+		}
+
+		for _, f := range fragments {
+			if e.types[f.Type] == nil {
+				e.types[f.Type] = newEstimateStats(f.Type.String())
+			}
+
+			stats.add(f.Text)
+			e.types[f.Type].add(f.Text)
+			e.total.add(f.Text)
+		}
+	}
+
+	e.files = append(e.files, stats)
+
+	return nil
+}
+
+func (e *estimate) print(out io.Writer, price float64) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+
+	row := func(s *estimateStats) {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t\n", s.name, s.fragments, s.uniqueFragments, s.characters, s.uniqueCharacters)
+	}
+
+	header := func(name string) {
+		fmt.Fprintf(w, "%s\tFragments\tUnique\tCharacters\tUnique\t\n", name)
+	}
+
+	header("File")
+	for _, s := range e.files {
+		row(s)
+	}
+
+	fmt.Fprintln(w, "\t\t\t\t\t")
+
+	types := make([]statictl.TranslationType, 0, len(e.types))
+	for t := range e.types {
+		types = append(types, t)
+	}
+
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+
+	header("Type")
+	for _, t := range types {
+		row(e.types[t])
+	}
+
+	fmt.Fprintln(w, "\t\t\t\t\t")
+
+	row(e.total)
+
+	w.Flush()
+
+	if price > 0 {
+		fmt.Fprintf(out, "Estimated cost: %.2f (%.2f without cache, at %g per character)\n",
+			float64(e.total.uniqueCharacters)*price, float64(e.total.characters)*price, price)
+	}
+}
+
+// estimateCommand reports how much text would be translated without sending anything to translation service
+func estimateCommand(args []string) error {
+	fs := flag.NewFlagSet("estimate", flag.ExitOnError)
+
+	var price float64
+
+	fs.Float64Var(&price, "price", 0, "Price per character sent to translation service, 0 skips cost estimate")
+
+	fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		return fmt.Errorf("estimate command requires patch directory as argument")
+	}
+
+	dir := fs.Arg(0)
+
+	if err := checkPatchVersion(dir); err != nil {
+		return err
+	}
+
+	patchDir = dir
+
+	if err := text.SetSourceLanguage(translateOptions.From); err != nil {
+		return err
+	}
+
+	if err := loadGlossary(); err != nil {
+		return err
+	}
+
+	block.Init()
+	block.SetGlossary(translateOptions.Glossary)
+
+	e := newEstimate()
+
+	for _, file := range sourceFiles(dir) {
+		if err := e.addFile(file); err != nil {
+			return err
+		}
+	}
+
+	e.print(os.Stdout, price)
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gitgud.io/softashell/rpgmaker-patch-translator/block"
+	"gitgud.io/softashell/rpgmaker-patch-translator/text"
+)
+
+func TestEstimate(t *testing.T) {
+	src := filepath.Join(wd, "testdata", "e2e")
+
+	_, cleanup := tempPatchDir(t)
+	defer cleanup()
+
+	err := checkPatchVersion(src)
+	check(err)
+
+	patchDir = src
+
+	err = text.SetSourceLanguage("ja")
+	check(err)
+
+	block.Init()
+
+	e := newEstimate()
+
+	err = e.addFile(filepath.Join(src, "Patch", "Map001.txt"))
+	check(err)
+
+	// こんにちは, 勇者 and が来た, choice is already translated and bgm name is skipped
+	s := e.total
+	if s.fragments != 3 || s.uniqueFragments != 3 || s.characters != 10 || s.uniqueCharacters != 10 {
+		t.Errorf("got %d fragments (%d unique) and %d characters (%d unique), want 3 (3) and 10 (10)",
+			s.fragments, s.uniqueFragments, s.characters, s.uniqueCharacters)
+	}
+
+	if len(e.files) != 1 || e.files[0].name != filepath.Join("Patch", "Map001.txt") {
+		t.Errorf("unexpected file stats %v", e.files)
+	}
+}
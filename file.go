@@ -1,280 +1,130 @@
 package main
 
 import (
-	"bufio"
-	"fmt"
+	"context"
 	"os"
 	"path/filepath"
-	"strings"
 
 	"gitgud.io/softashell/rpgmaker-patch-translator/block"
-	"gitgud.io/softashell/rpgmaker-patch-translator/text"
-
-	log "github.com/sirupsen/logrus"
-	"github.com/dimchansky/utfbom"
+	"gitgud.io/softashell/rpgmaker-patch-translator/patch"
 	"github.com/pkg/errors"
 
 	"github.com/vbauerster/mpb"
 	"github.com/vbauerster/mpb/decor"
 )
 
-type patchFile struct {
-	path    string
-	version string
-	blocks  []block.PatchBlock
-}
-
-func writePatchFile(patch patchFile) error {
-	log.Debugf("Writing %s", patch.path)
-
-	err := os.Remove(patch.path)
-	check(err)
-
-	f, err := os.Create(patch.path)
-	check(err)
-	defer f.Close()
+// translatePatch translates every block in patch. Once stop is done no new blocks are started,
+// blocks in progress keep going until ctx is done. Returned error is stop.Err() if any block was skipped
+func translatePatch(ctx, stop context.Context, p *mpb.Progress, pf patch.File) (patch.File, error) {
+	blockCount := len(pf.Blocks)
 
-	w := bufio.NewWriter(f)
+	block.AddDialogueHistory(pf.Blocks, historyLines)
 
-	_, err = w.WriteString(fmt.Sprintf("> %s\n", patch.version))
-	check(err)
+	jobs, results := createBlockWorkers(ctx, blockCount)
 
-	for _, b := range patch.blocks {
-		_, err = w.WriteString("> BEGIN STRING\n")
-		check(err)
-
-		_, err = w.WriteString(text.Escape(b.Original))
-		check(err)
+	bar := p.AddBar(int64(blockCount), mpb.BarRemoveOnComplete(),
+		mpb.PrependDecorators(
+			decor.Name(filepath.Base(pf.Path), decor.WC{W: 25, C: decor.DSyncSpace}),
+			decor.CountersNoUnit("%d / %d", decor.WC{C: decor.DSyncSpace}),
+		))
 
-		for _, t := range b.Translations {
-			for _, context := range t.Contexts {
-				context = fmt.Sprintf("> CONTEXT%s", context)
+	// Add blocks in background to job queue
+	go func() {
+		defer close(jobs)
 
-				if !t.Translated {
-					context += " < UNTRANSLATED\n"
-				} else {
-					context += "\n"
-				}
-				_, err = w.WriteString(context)
-				check(err)
+		for i, block := range pf.Blocks {
+			select {
+			case jobs <- blockWork{i, block}:
+			case <-stop.Done():
+				return
 			}
+		}
+	}()
 
-			var trans string
-
-			if t.Translated {
-				text := text.Escape(t.Text)
-
-				if t.Touched && block.ShouldBreakLines(t.Contexts) {
-					trans = breakLines(text)
-					if !strings.HasSuffix(trans, "\n") {
-						trans += "\n"
-					}
-				} else {
-					trans = text
-					if !strings.HasSuffix(trans, "\n") {
-						trans += "\n"
-					}
-				}
-			} else {
-				trans = "\n"
-			}
+	var done int
 
-			_, err = w.WriteString(trans)
-			check(err)
-		}
+	// Start reading results, will block if there are none
+	for j := range results {
+		pf.Blocks[j.id] = j.block
+		bar.Increment()
 
-		_, err = w.WriteString("> END STRING\n\n")
-		check(err)
+		done++
 	}
 
-	err = w.Flush()
-	check(err)
+	if done < blockCount || ctx.Err() != nil {
+		p.Abort(bar, true)
 
-	log.Debugf("Done writing %s", patch.path)
+		return pf, stop.Err()
+	}
 
-	return nil
+	return pf, nil
 }
 
-func parsePatchFile(file string) (patchFile, error) {
-	log.Debugf("Parsing %q", filepath.Base(file))
-
-	patch := patchFile{path: file}
+// processFile translates and writes a single patch file. If it's stopped midway file is still
+// written with blocks that were finished and stop.Err() is returned, files that weren't started are left untouched.
+// In dry run file isn't written and diff of changes is kept instead
+func processFile(ctx, stop context.Context, p *mpb.Progress, file string) error {
+	if err := stop.Err(); err != nil {
+		return err
+	}
 
-	f, err := os.Open(file)
+	pf, err := readSource(file)
 	if err != nil {
-		return patch, errors.Wrapf(err, "failed to open patch file: %q", file)
+		return err
 	}
-	defer f.Close()
-
-	s := bufio.NewScanner(utfbom.SkipOnly(f))
-	s.Split(bufio.ScanLines)
-
-	original := false
-	translation := false
-
-	var orig string
-	var trans string
-	var contexts []string
-
-	var currentBlock block.PatchBlock
-	var translations []block.TranslationBlock
-
-	for s.Scan() {
-		l := s.Text()
-
-		if strings.HasPrefix(l, "> ") {
-			l = l[2:]
-
-			switch {
-			case strings.HasPrefix(l, "RPGMAKER TRANS PATCH FILE VERSION") || strings.HasPrefix(l, "WOLF TRANS PATCH FILE VERSION 1.0"):
-				patch.version = l
-			case strings.HasPrefix(l, "BEGIN STRING"):
-				original = true
-			case strings.HasPrefix(l, "CONTEXT"):
-				if translation && len(trans) > 0 {
-					var translated bool
-
-					if len(strings.TrimRight(trans, "\n")) < 1 {
-						trans = ""
-						translated = false
-					} else {
-						translated = true
-					}
-
-					translations = append(translations, block.TranslationBlock{
-						Text:       trans,
-						Contexts:   contexts,
-						Translated: translated,
-					})
-
-					trans = ""
-					contexts = nil
-					translation = false
-				} else {
-					original = false
-					translation = true
-				}
-
-				if len(l) > len("CONTEXT")+1 {
-					start := len("CONTEXT")
-					end := strings.Index(l, " < UNTRANSLATED")
-					if end == -1 {
-						contexts = append(contexts, l[start:])
-					} else {
-						contexts = append(contexts, l[start:end])
-					}
-				}
-			case strings.HasPrefix(l, "END STRING"):
-				translation = false
 
-				if len(trans) > 0 {
-					var translated bool
+	logDiagnostics(pf.Diagnostics)
 
-					if len(strings.TrimRight(trans, "\n")) < 1 {
-						trans = ""
-						translated = false
-					} else {
-						translated = true
-					}
-
-					translations = append(translations, block.TranslationBlock{
-						Text:       text.Unescape(trans),
-						Contexts:   contexts,
-						Translated: translated,
-					})
-				} else if len(contexts) > 0 {
-					translations = append(translations, block.TranslationBlock{
-						Text:       text.Unescape(trans),
-						Contexts:   contexts,
-						Translated: false,
-					})
-				} else if len(translations) == 0 {
-					log.Errorf("No contexts found for block with original text:\n%q", orig)
-				}
-
-				currentBlock.Original = text.Unescape(orig)
-				currentBlock.Translations = translations
-
-				patch.blocks = append(patch.blocks, currentBlock)
-
-				orig = ""
-				trans = ""
-
-				contexts = nil
-				translations = nil
-			default:
-				log.Warn("Unknown input:", l)
-			}
+	// Writing back a file that wasn't understood could lose parts of it
+	if patch.HasErrors(pf.Diagnostics) {
+		return errors.Errorf("%q has errors, check it with validate command", file)
+	}
 
-			continue
-		}
+	// Translation changes blocks in place
+	old := pf
+	old.Blocks = patch.CopyBlocks(pf.Blocks)
 
-		if !strings.HasSuffix(l, "\n") && (original || translation) {
-			l += "\n"
-		}
+	// Remaining blocks stay untranslated so next run carries on with them
+	pf, stopErr := translatePatch(ctx, stop, p, pf)
 
-		if original {
-			orig += l
-		} else if translation {
-			trans += l
+	if dryRun {
+		if err := addDiff(old, pf); err != nil {
+			return err
 		}
-	}
-
-	if err := s.Err(); err != nil {
-		return patch, errors.Wrapf(err, "error while scanning patch file: %q", file)
-	}
 
-	if len(patch.version) < 3 {
-		err = fmt.Errorf("No patch version found in %q", file)
+		return stopErr
 	}
 
-	return patch, err
-}
-
-func translatePatch(p *mpb.Progress, patch patchFile) (patchFile, error) {
-	blockCount := len(patch.blocks)
-
-	jobs, results := createBlockWorkers(blockCount)
-
-	bar := p.AddBar(int64(blockCount), mpb.BarRemoveOnComplete(),
-		mpb.PrependDecorators(
-			decor.Name(filepath.Base(patch.path), decor.WC{W: 25, C: decor.DSyncSpace}),
-			decor.CountersNoUnit("%d / %d", decor.WC{C: decor.DSyncSpace}),
-		))
-
-	// Add blocks in background to job queue
-	go func() {
-		for i, block := range patch.blocks {
-			w := blockWork{i, block}
-			jobs <- w
+	if len(outputDir) > 0 {
+		pf.Path, err = outputPath(file)
+		if err != nil {
+			return err
 		}
-		close(jobs)
-	}()
-
-	// Start reading results, will block if there are none
-	for j := range results {
-		patch.blocks[j.id] = j.block
-		bar.Increment()
+	} else if err := backupPatch(); err != nil {
+		return err
 	}
 
-	return patch, nil
-}
-
-func processFile(p *mpb.Progress, file string) error {
-	patch, err := parsePatchFile(file)
+	err = writeSource(file, pf)
 	if err != nil {
 		return err
 	}
 
-	patch, err = translatePatch(p, patch)
+	return stopErr
+}
+
+// outputPath returns where file from patch directory is written in output directory
+func outputPath(file string) (string, error) {
+	rel, err := filepath.Rel(patchDir, file)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	err = writePatchFile(patch)
+	path := filepath.Join(outputDir, rel)
+
+	err = os.MkdirAll(filepath.Dir(path), 0755)
 	if err != nil {
-		return err
+		return "", errors.Wrapf(err, "failed to create output directory for %q", rel)
 	}
 
-	return nil
+	return path, nil
 }
@@ -6,6 +6,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"gitgud.io/softashell/rpgmaker-patch-translator/patch"
 )
 
 func TestPatchFileParsing(t *testing.T) {
@@ -15,7 +17,7 @@ func TestPatchFileParsing(t *testing.T) {
 	}
 
 	for _, inputFile := range fileList {
-		patch, err := parsePatchFile(inputFile)
+		pf, err := patch.ReadFile(inputFile, strictPatch)
 		check(err)
 
 		file, err := ioutil.TempFile(os.TempDir(), "")
@@ -26,9 +28,9 @@ func TestPatchFileParsing(t *testing.T) {
 
 		outputFile := filepath.Join(os.TempDir(), info.Name())
 
-		patch.path = outputFile
+		pf.Path = outputFile
 
-		err = writePatchFile(patch)
+		err = patch.WriteFile(pf, breakLines)
 		check(err)
 
 		input, err := ioutil.ReadFile(inputFile)
@@ -36,7 +38,7 @@ func TestPatchFileParsing(t *testing.T) {
 		output, err := ioutil.ReadFile(outputFile)
 		check(err)
 
-		err = os.Remove(patch.path)
+		err = os.Remove(pf.Path)
 		check(err)
 
 		if !bytes.Equal(input, output) {
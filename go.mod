@@ -1,16 +1,21 @@
 module gitgud.io/softashell/rpgmaker-patch-translator
 
+go 1.21
+
 require (
-	github.com/Jeffail/tunny v0.0.0-20181108205650-4921fff29480
-	github.com/VividCortex/ewma v1.1.1 // indirect
 	github.com/davecgh/go-spew v1.1.1
 	github.com/dimchansky/utfbom v1.1.0
 	github.com/hjson/hjson-go v3.0.0+incompatible
-	github.com/mattn/go-isatty v0.0.4 // indirect
 	github.com/pkg/errors v0.8.0
 	github.com/sirupsen/logrus v1.2.0
 	github.com/vbauerster/mpb v3.3.3+incompatible
+	golang.org/x/text v0.3.0
+)
+
+require (
+	github.com/VividCortex/ewma v1.1.1 // indirect
+	github.com/konsorten/go-windows-terminal-sequences v1.0.1 // indirect
+	github.com/mattn/go-isatty v0.0.4 // indirect
 	golang.org/x/crypto v0.0.0-20181203042331-505ab145d0a9 // indirect
 	golang.org/x/sys v0.0.0-20181220182059-7c4c994c65f7 // indirect
-	golang.org/x/text v0.3.0
 )
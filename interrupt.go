@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// watchInterrupts calls stop on first SIGINT/SIGTERM or when deadline runs out so no new blocks are started,
+// translations in progress are cancelled after grace period or on second signal. It returns once ctx is done
+func watchInterrupts(ctx context.Context, stop, cancel context.CancelFunc) {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	var timeout <-chan time.Time
+	if deadline > 0 {
+		timeout = time.After(deadline)
+	}
+
+	select {
+	case <-signals:
+		log.Warnf("Stopping, waiting up to %s for translations in progress. Interrupt again to stop now", grace)
+	case <-timeout:
+		log.Warnf("Deadline reached, waiting up to %s for translations in progress", grace)
+	case <-ctx.Done():
+		return
+	}
+
+	stop()
+
+	select {
+	case <-signals:
+	case <-time.After(grace):
+	case <-ctx.Done():
+		return
+	}
+
+	cancel()
+}
@@ -1,6 +1,7 @@
 package lex
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -114,10 +115,12 @@ func assembleItems(items []Item) string {
 	return out
 }
 
-func TranslateItems(items []Item) (string, error) {
+// TranslateItems translates text items and assembles them back into a single string,
+// preceding lines are passed to translator as context, it stops when ctx is done
+func TranslateItems(ctx context.Context, items []Item, preceding []string) (string, error) {
 	for i := range items {
 		if items[i].Typ == ItemText {
-			translation, err := translate.String(items[i].Val)
+			translation, err := translate.String(ctx, items[i].Val, preceding)
 			if err != nil {
 				return "", errors.Wrapf(err, "failed to translate [%s] %q", items[i].Typ, items[i].Val)
 			}
@@ -135,7 +138,7 @@ func TranslateItems(items []Item) (string, error) {
 			text := items[i].Val
 			text = width.Narrow.String(text)
 
-			text, err := translate.String(text)
+			text, err := translate.String(ctx, text, preceding)
 			if err != nil {
 				return "", errors.Wrapf(err, "failed to translate [%s] %q", items[i].Typ, items[i].Val)
 			}
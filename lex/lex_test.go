@@ -1,9 +1,12 @@
 package lex
 
 import (
+	"context"
+	"strings"
 	"testing"
 
 	"gitgud.io/softashell/rpgmaker-patch-translator/text"
+	"gitgud.io/softashell/rpgmaker-patch-translator/translate"
 	"github.com/davecgh/go-spew/spew"
 	log "github.com/sirupsen/logrus"
 )
@@ -322,3 +325,51 @@ func TestNumberExtraction(t *testing.T) {
 	}
 }
 */
+
+func TestTranslateItemsPseudo(t *testing.T) {
+	err := translate.Init(translate.Options{Backend: "pseudo", PseudoRatio: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer translate.Close()
+
+	var tests = []struct {
+		input string
+		kept  []string
+	}{
+		{
+			`\C[2]勇者\C[0]が来た`,
+			[]string{`\C[2]`, `\C[0]`},
+		},
+		{
+			`%sの%sを %s 奪った`,
+			[]string{`%s`},
+		},
+		{
+			`#{$game_actors[1].name}さん、おはよう`,
+			[]string{`#{$game_actors[1].name}`},
+		},
+	}
+
+	for _, tt := range tests {
+		items, err := ParseText(tt.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out, err := TranslateItems(context.Background(), items, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, k := range tt.kept {
+			if strings.Count(out, k) != strings.Count(tt.input, k) {
+				t.Errorf("For input:\n%q\nescape code %q wasn't kept in:\n%q", tt.input, k, out)
+			}
+		}
+
+		if text.ShouldTranslate(getOnlyText(out)) {
+			t.Errorf("For input:\n%q\ntext wasn't replaced:\n%q", tt.input, out)
+		}
+	}
+}
@@ -2,9 +2,12 @@ package main
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"sync"
 
+	"gitgud.io/softashell/rpgmaker-patch-translator/translate"
+
 	"github.com/davecgh/go-spew/spew"
 	log "github.com/sirupsen/logrus"
 )
@@ -27,6 +30,11 @@ func logBlockError(err error, args ...interface{}) {
 
 	log.Error(out)
 
+	// Dry run only logs to stderr
+	if dryRun {
+		return
+	}
+
 	f, err := os.OpenFile("errors.txt", os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
 	if err != nil {
 		log.Error("Unable to open error log file", err)
@@ -38,3 +46,26 @@ func logBlockError(err error, args ...interface{}) {
 		log.Error("Unable to write in error log file", err)
 	}
 }
+
+// writeFlags saves translations that should be checked by a human
+func writeFlags(file string) error {
+	list := translate.Flags()
+	if len(list) < 1 {
+		return nil
+	}
+
+	var out string
+
+	for _, f := range list {
+		out += fmt.Sprintf("%s\nOriginal: %q\nTranslation: %q\n\n", f.Reason, f.Text, f.Translation)
+	}
+
+	err := ioutil.WriteFile(file, []byte(out), 0644)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%d translations were flagged for review, see %s\n", len(list), file)
+
+	return nil
+}
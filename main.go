@@ -2,17 +2,23 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
 
 	"gitgud.io/softashell/rpgmaker-patch-translator/block"
 	"gitgud.io/softashell/rpgmaker-patch-translator/engine"
+	"gitgud.io/softashell/rpgmaker-patch-translator/mv"
+	"gitgud.io/softashell/rpgmaker-patch-translator/statictl"
 	"gitgud.io/softashell/rpgmaker-patch-translator/translate"
 
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -23,6 +29,25 @@ var (
 
 	cFileThreads  int
 	cBlockThreads int
+
+	historyLines int
+
+	deadline time.Duration
+	grace    time.Duration
+
+	memoryFile      string
+	memoryThreshold float64
+
+	configFile    string
+	outputDir     string
+	patchDir      string
+	backupEnabled bool
+	strictPatch   bool
+	dryRun        bool
+	diffFile      string
+
+	translateOptions translate.Options
+	restHeaders      headerFlags
 )
 
 func main() {
@@ -33,13 +58,66 @@ func main() {
 		log.Fatal("Program requires patch directory as argument")
 	}
 
+	if args[0] == "cache" {
+		if err := cacheCommand(args[1:]); err != nil {
+			log.Fatal(err)
+		}
+
+		return
+	}
+
+	if args[0] == "restore" {
+		if err := restoreCommand(args[1:]); err != nil {
+			log.Fatal(err)
+		}
+
+		return
+	}
+
+	if args[0] == "validate" {
+		if err := validateCommand(args[1:]); err != nil {
+			log.Fatal(err)
+		}
+
+		return
+	}
+
+	if args[0] == "migrate" {
+		if err := migrateCommand(args[1:]); err != nil {
+			log.Fatal(err)
+		}
+
+		return
+	}
+
+	if args[0] == "estimate" {
+		if err := estimateCommand(args[1:]); err != nil {
+			log.Fatal(err)
+		}
+
+		return
+	}
+
 	dir := args[0]
 	err := checkPatchVersion(dir)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	fileList := getDirectoryContents(filepath.Join(dir, "Patch"))
+	patchDir = dir
+
+	if len(diffFile) > 0 {
+		dryRun = true
+	}
+
+	if len(outputDir) > 0 && !dryRun {
+		err = copyPatchVersion(dir, outputDir)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	fileList := sourceFiles(dir)
 	if len(fileList) < 1 {
 		log.Fatal("Couldn't find anything to translate")
 	}
@@ -55,38 +133,175 @@ func main() {
 	fmt.Println("Current settings:")
 	fmt.Println("- line length:", lineLength)
 	fmt.Println("- line length tolerance:", lineTolerance)
+	fmt.Println("- translation backend:", translateOptions.Backend)
+	if dryRun {
+		fmt.Println("- dry run, patch files are left unchanged")
+	} else if len(outputDir) > 0 {
+		fmt.Println("- output directory:", outputDir)
+	}
+	fmt.Printf("- languages: %s -> %s\n", translateOptions.From, translateOptions.To)
 
 	fileCount := len(fileList)
 
 	fmt.Printf("Found %d files to translate\n", fileCount)
 
-	translate.Init()
+	if translateOptions.RESTHeaders == nil {
+		translateOptions.RESTHeaders = make(map[string]string)
+	}
+
+	for k, v := range restHeaders.Map() {
+		translateOptions.RESTHeaders[k] = v
+	}
+
+	if err := loadGlossary(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Dry run changes nothing on disk
+	translateOptions.CacheReadOnly = dryRun
+
+	err = translate.Init(translateOptions)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	block.Init()
+	block.SetGlossary(translateOptions.Glossary)
+
+	if memoryThreshold > 0 {
+		mem, err := buildMemory(fileList)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		block.SetMemory(mem)
+	}
+
+	// Ctrl-C stops starting new blocks, ctx is cancelled once translations in progress had time to finish
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-	jobs, results := createFileWorkers(fileCount)
+	stop, stopDispatch := context.WithCancel(ctx)
+	defer stopDispatch()
+
+	go watchInterrupts(ctx, stopDispatch, cancel)
+
+	jobs, results := createFileWorkers(ctx, stop, fileCount)
 
 	go func() {
+		defer close(jobs)
+
 		for _, file := range fileList {
-			jobs <- file
+			select {
+			case jobs <- file:
+			case <-stop.Done():
+				return
+			}
 		}
-		close(jobs)
 	}()
 
 	for err := range results {
+		if err != nil && errors.Cause(err) != context.Canceled {
+			log.Error(err)
+		}
+	}
+
+	stopped := stop.Err() != nil
+	cancel()
+
+	if dryRun {
+		err = writeDiffs()
+		if err != nil {
+			log.Error(err)
+		}
+	}
+
+	err = translate.Close()
+	if err != nil {
+		log.Error(err)
+	}
+
+	printCacheStats()
+
+	// Dry run leaves every file alone, only the diff is written
+	if dryRun {
+		if n := len(translate.Flags()); n > 0 {
+			fmt.Printf("%d translations would be flagged for review\n", n)
+		}
+	} else {
+		err = writeFlags("flagged.txt")
 		if err != nil {
 			log.Error(err)
 		}
+
+		if len(translateOptions.Compare) > 0 {
+			err = writeComparisons(filepath.Join(dir, "candidates.json"))
+			if err != nil {
+				log.Error(err)
+			}
+		}
+	}
+
+	if stopped && !dryRun {
+		fmt.Println("Translation was stopped early, finished blocks were saved and the rest will be translated on next run")
+	}
+
+	if failedBlocks > 0 {
+		if dryRun {
+			fmt.Printf("Failed to translate %d blocks, see errors above for details\n", failedBlocks)
+		} else {
+			fmt.Printf("Failed to translate %d blocks, they were left untranslated. See errors.txt for details\n", failedBlocks)
+		}
 	}
 
 	fmt.Printf("Finished in %s\n", time.Since(start))
 }
 
+// loadGlossary reads glossary file once, the same terms are used for placeholders and by compare policy
+func loadGlossary() error {
+	g, err := statictl.LoadGlossary(translateOptions.GlossaryFile)
+	if err != nil {
+		return errors.Wrap(err, "failed to load glossary")
+	}
+
+	translateOptions.Glossary = g
+
+	return nil
+}
+
 func checkPatchVersion(dir string) error {
+	e, err := detectEngine(dir)
+	if err != nil {
+		return err
+	}
+
+	switch e {
+	case engine.RPGMVX:
+		fmt.Println("Detected RPG Maker VX Ace Patch")
+	case engine.Wolf:
+		fmt.Println("Detected WOLF RPG Patch")
+	case engine.MV:
+		fmt.Println("Detected RPG Maker MV/MZ game, data files are translated directly")
+	default:
+		return nil
+	}
+
+	engine.Set(e)
+
+	return nil
+}
+
+// detectEngine reads version header of the patch without changing anything
+func detectEngine(dir string) (engine.EngineType, error) {
 	file, err := os.Open(filepath.Join(dir, "RPGMKTRANSPATCH"))
 	if err != nil {
 		file, err = os.Open(filepath.Join(dir, "Patch", "dump", "GameDat.txt"))
 		if err != nil {
-			return fmt.Errorf("Unable to open RPGMKTRANSPATCH or Patch/dump/GameDat.txt")
+			if len(mv.DataDir(dir)) > 0 {
+				return engine.MV, nil
+			}
+
+			return engine.None, fmt.Errorf("Unable to open RPGMKTRANSPATCH or Patch/dump/GameDat.txt and no MV/MZ data directory found")
 		}
 	}
 	defer file.Close()
@@ -96,33 +311,60 @@ func checkPatchVersion(dir string) error {
 		text := scanner.Text()
 
 		if text == "> RPGMAKER TRANS PATCH V3" {
-			fmt.Println("Detected RPG Maker VX Ace Patch")
-
-			engine.Set(engine.RPGMVX)
-
-			return nil
+			return engine.RPGMVX, nil
 		} else if text == "> WOLF TRANS PATCH FILE VERSION 1.0" {
-			fmt.Println("Detected WOLF RPG Patch")
-
-			engine.Set(engine.Wolf)
-
-			return nil
+			return engine.Wolf, nil
 		}
 
-		return fmt.Errorf("Unsupported patch version")
+		return engine.None, fmt.Errorf("Unsupported patch version")
 	}
 
 	if err := scanner.Err(); err != nil {
 		log.Fatal(err)
 	}
 
-	return err
+	return engine.None, nil
+}
+
+// copyPatchVersion copies files used to detect patch version to output directory
+func copyPatchVersion(dir, output string) error {
+	for _, name := range []string{"RPGMKTRANSPATCH", filepath.Join("Patch", "dump", "GameDat.txt")} {
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		file := filepath.Join(output, name)
+
+		err = os.MkdirAll(filepath.Dir(file), 0755)
+		if err != nil {
+			return err
+		}
+
+		err = ioutil.WriteFile(file, data, 0644)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func getDirectoryContents(dir string) []string {
 	var fileList []string
 
 	err := filepath.Walk(dir, func(path string, f os.FileInfo, err error) error {
+		if err != nil {
+			// Missing directory has nothing to translate, callers report it
+			if path == dir && os.IsNotExist(err) {
+				return nil
+			}
+
+			return err
+		}
+
 		if f.IsDir() || filepath.Ext(path) != ".txt" {
 			return nil
 		}
@@ -145,6 +387,34 @@ func check(err error) {
 	}
 }
 
+// headerFlags collects repeated "Name: value" flags
+type headerFlags []string
+
+func (h *headerFlags) String() string {
+	return strings.Join(*h, ", ")
+}
+
+func (h *headerFlags) Set(value string) error {
+	if !strings.Contains(value, ":") {
+		return fmt.Errorf("header %q should be in \"Name: value\" format", value)
+	}
+
+	*h = append(*h, value)
+
+	return nil
+}
+
+func (h headerFlags) Map() map[string]string {
+	headers := make(map[string]string)
+
+	for _, header := range h {
+		parts := strings.SplitN(header, ":", 2)
+		headers[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+
+	return headers
+}
+
 func parseFlags() []string {
 	flag.IntVar(&lineLength, "length", -1, "Max line legth")
 	flag.IntVar(&lineTolerance, "tolerance", 5, "Max amount of characters allowed to go over the line limit")
@@ -152,7 +422,64 @@ func parseFlags() []string {
 	flag.IntVar(&cFileThreads, "filethreads", runtime.NumCPU()/2+1, "Amount of threads to use for processing files")
 	flag.IntVar(&cBlockThreads, "blockthreads", runtime.NumCPU()*2+1, "Amount of threads to use for processing blocks in each file")
 
+	flag.StringVar(&translateOptions.Backend, "backend", "comfy", fmt.Sprintf("Translation backend to use %v, comma separated list of backends is tried in order", translate.Backends()))
+	flag.StringVar(&translateOptions.Compare, "compare", "", "Ask every backend and pick translation by policy [first shortest glossary], candidates are saved in candidates.json")
+	flag.StringVar(&translateOptions.GlossaryFile, "glossary", filepath.Join("database", "glossary.hjson"), "Glossary of terms that always use the same translation, they are replaced with placeholders before translation")
+
+	flag.StringVar(&translateOptions.Validate, "validate", "placeholder,escape", fmt.Sprintf("Comma separated list of rules translations are checked with %v, failed translations are retried and flagged, empty string disables validation", translate.Rules()))
+	flag.IntVar(&translateOptions.ValidateRetries, "validateretries", 1, "Amount of times to ask the same backend again when translation fails validation before trying next backend, stops early if it returns the same text again")
+	flag.Float64Var(&translateOptions.MaxLengthRatio, "maxlengthratio", 8, "Max length of translation compared to original allowed by ratio rule")
+
+	flag.IntVar(&historyLines, "history", 3, "Amount of preceding dialogue lines sent as context to backends that support it")
+
+	flag.StringVar(&memoryFile, "memory", filepath.Join("database", "memory.jsonl"), "Translation memory file shared between runs, empty string only uses translations from current patch")
+	flag.Float64Var(&memoryThreshold, "memorythreshold", 1, "Min similarity of text to reuse its translation from memory, 1 only allows exact matches, lower values reuse similar text and flag it for review, 0 disables memory")
+
+	flag.StringVar(&translateOptions.From, "from", "ja", "Language of the game")
+	flag.StringVar(&translateOptions.To, "to", "en", "Language to translate to")
+
+	flag.StringVar(&translateOptions.Address, "address", "127.0.0.1:3000", "Address of comfy-translator service")
+	flag.IntVar(&translateOptions.Retries, "retries", 5, "Amount of times to retry failed translation request before leaving block untranslated")
+	flag.DurationVar((*time.Duration)(&translateOptions.RetryDelay), "retrydelay", time.Second, "Delay before first retry, doubled after every failed attempt")
+	flag.DurationVar((*time.Duration)(&translateOptions.Timeout), "timeout", time.Minute, "Max time to wait for a single translation request before retrying it, 0 waits forever")
+	flag.DurationVar(&deadline, "deadline", 0, "Stop translating after this long and save what's finished, 0 runs until everything is translated")
+	flag.DurationVar(&grace, "grace", 30*time.Second, "How long to wait for translations in progress after interrupt or deadline before abandoning them")
+
+	flag.IntVar(&translateOptions.MaxInFlight, "maxinflight", 64, "Max amount of translation requests processed at once")
+	flag.IntVar(&translateOptions.MaxConnections, "maxconns", 16, "Max amount of connections to translation service")
+	flag.Float64Var(&translateOptions.RequestsPerSecond, "rps", 0, "Max amount of translation requests sent every second, 0 is unlimited")
+
+	flag.IntVar(&translateOptions.BatchSize, "batchsize", 1, "Max amount of texts sent in one request to backends that support it")
+	flag.DurationVar((*time.Duration)(&translateOptions.BatchDelay), "batchdelay", 50*time.Millisecond, "How long to wait for more texts before sending incomplete batch")
+
+	flag.StringVar(&translateOptions.CacheFile, "cache", filepath.Join("database", "cache.jsonl"), "Translation cache file, empty string disables cache")
+
+	flag.StringVar(&translateOptions.RESTURL, "resturl", "", "URL of translation service used by rest backend")
+	flag.StringVar(&translateOptions.RESTField, "restfield", "translationText", "Dot separated path to translated text in rest backend response")
+	flag.StringVar(&translateOptions.RESTBatch, "restbatch", "", "Dot separated path to array of translations in rest backend batch response")
+	flag.Var(&restHeaders, "restheader", "Header sent with every rest backend request as \"Name: value\", can be repeated")
+
+	flag.BoolVar(&dryRun, "dryrun", false, "Translate without changing patch files and print diff of what would change")
+	flag.StringVar(&diffFile, "diff", "", "Write dry run diff to this file instead of stdout, implies -dryrun")
+	flag.StringVar(&outputDir, "output", "", "Write translated patch to this directory instead of replacing original files")
+	flag.BoolVar(&strictPatch, "strict", false, "Fail on unknown patch directives instead of keeping them")
+	flag.BoolVar(&backupEnabled, "backup", true, "Copy Patch directory to backup directory before it's modified, restore it with restore command")
+	flag.Float64Var(&translateOptions.PseudoRatio, "pseudoratio", 2.5, "How many times longer pseudo backend output is compared to original text")
+
+	flag.StringVar(&configFile, "config", "", "Load settings from hjson config file, flags take priority over it")
+
 	flag.Parse()
 
+	if len(configFile) > 0 {
+		err := loadConfig(configFile, &translateOptions)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		// Parse again so flags overwrite values from config
+		restHeaders = nil
+		flag.Parse()
+	}
+
 	return flag.Args()
 }
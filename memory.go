@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+
+	"gitgud.io/softashell/rpgmaker-patch-translator/memory"
+	"github.com/pkg/errors"
+)
+
+// buildMemory loads translation memory from previous runs and adds translations
+// found in patch to it, blocks translated by backends during this run are left out
+func buildMemory(fileList []string) (*memory.Memory, error) {
+	mem := memory.New(memoryThreshold)
+
+	if len(memoryFile) > 0 {
+		if err := mem.Load(memoryFile); err != nil {
+			return nil, err
+		}
+	}
+
+	loaded := mem.Len()
+
+	for _, file := range fileList {
+		pf, err := readSource(file)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to build translation memory")
+		}
+
+		for _, b := range pf.Blocks {
+			for _, t := range b.Translations {
+				// Text translated by backends in this run isn't a reference for other blocks
+				if t.Translated && !t.Touched {
+					mem.Add(b.Original, t.Text)
+				}
+			}
+		}
+	}
+
+	fmt.Printf("Translation memory has %d entries, %d from this patch\n", mem.Len(), mem.Len()-loaded)
+
+	// Dry run doesn't change anything on disk
+	if len(memoryFile) > 0 && !dryRun {
+		if err := mem.Save(memoryFile); err != nil {
+			return nil, err
+		}
+	}
+
+	return mem, nil
+}
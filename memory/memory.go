@@ -0,0 +1,262 @@
+package memory
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"gitgud.io/softashell/rpgmaker-patch-translator/text"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/text/width"
+)
+
+// Entry is a known translation of original text
+type Entry struct {
+	Original    string `json:"original"`
+	Translation string `json:"translation"`
+}
+
+// Match is an entry found for looked up text
+type Match struct {
+	Entry
+	Similarity float64 // 1 for exact match
+}
+
+// Memory finds existing translations of the same or similar text. Safe for concurrent use.
+type Memory struct {
+	threshold float64
+
+	mutex    sync.RWMutex
+	entries  map[string]Entry    // By normalized original
+	byLength map[int][]string    // Normalized originals grouped by length
+	byGram   map[string][]string // Normalized originals by pairs of runes they contain
+}
+
+// New creates empty memory, fuzzy matches need at least threshold similarity,
+// only exact matches are returned if threshold is outside of (0, 1) range
+func New(threshold float64) *Memory {
+	return &Memory{
+		threshold: threshold,
+		entries:   make(map[string]Entry),
+		byLength:  make(map[int][]string),
+		byGram:    make(map[string][]string),
+	}
+}
+
+// bigrams returns distinct pairs of adjacent runes in str
+func bigrams(str string) []string {
+	r := []rune(str)
+
+	seen := make(map[string]bool)
+
+	var grams []string
+
+	for i := 0; i+1 < len(r); i++ {
+		g := string(r[i : i+2])
+		if !seen[g] {
+			seen[g] = true
+			grams = append(grams, g)
+		}
+	}
+
+	return grams
+}
+
+// Normalize returns text the way it's compared, ignoring whitespace and width of characters
+func Normalize(str string) string {
+	str = width.Fold.String(str)
+
+	return strings.Join(strings.Fields(str), "")
+}
+
+// Add stores translation, first translation of the same text is kept
+func (m *Memory) Add(original, translation string) {
+	key := Normalize(original)
+	if len(key) < 1 || len(strings.TrimSpace(translation)) < 1 {
+		return
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, ok := m.entries[key]; ok {
+		return
+	}
+
+	m.entries[key] = Entry{original, translation}
+
+	n := utf8.RuneCountInString(key)
+	m.byLength[n] = append(m.byLength[n], key)
+
+	for _, g := range bigrams(key) {
+		m.byGram[g] = append(m.byGram[g], key)
+	}
+}
+
+// Len returns amount of stored translations
+func (m *Memory) Len() int {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return len(m.entries)
+}
+
+// Lookup returns exact match or the most similar entry above threshold
+func (m *Memory) Lookup(original string) (Match, bool) {
+	key := Normalize(original)
+	if len(key) < 1 {
+		return Match{}, false
+	}
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if e, ok := m.entries[key]; ok {
+		return Match{e, 1}, true
+	}
+
+	if m.threshold <= 0 || m.threshold >= 1 {
+		return Match{}, false
+	}
+
+	n := utf8.RuneCountInString(key)
+
+	// Strings with too different length can't be similar enough
+	maxDiff := int(float64(n) * (1 - m.threshold) / m.threshold)
+
+	// Each edit removes at most two pairs of runes, so candidates that share too few pairs
+	// with key are skipped without computing edit distance. Short strings that don't need
+	// to share any pairs are compared with every remembered string of their length
+	grams := bigrams(key)
+
+	required := func(l int) int {
+		longest := n
+		if l > longest {
+			longest = l
+		}
+
+		edits := int(float64(longest)*(1-m.threshold) + 1e-9)
+
+		return len(grams) - 2*edits
+	}
+
+	var best Match
+	var bestKey string
+	var found bool
+
+	compare := func(candidate string) {
+		s := text.Similarity(key, candidate)
+		if s < m.threshold || s < best.Similarity {
+			return
+		}
+
+		// Same similarity picks the same entry every time
+		if s == best.Similarity && candidate > bestKey {
+			return
+		}
+
+		best, bestKey, found = Match{m.entries[candidate], s}, candidate, true
+	}
+
+	for l := n - maxDiff; l <= n+maxDiff; l++ {
+		if required(l) <= 0 {
+			for _, candidate := range m.byLength[l] {
+				compare(candidate)
+			}
+		}
+	}
+
+	shared := make(map[string]int)
+
+	for _, g := range grams {
+		for _, candidate := range m.byGram[g] {
+			shared[candidate]++
+		}
+	}
+
+	for candidate, count := range shared {
+		l := utf8.RuneCountInString(candidate)
+		if l < n-maxDiff || l > n+maxDiff {
+			continue
+		}
+
+		if need := required(l); need > 0 && count >= need {
+			compare(candidate)
+		}
+	}
+
+	return best, found
+}
+
+// Load adds entries saved in file, missing file is not an error
+func (m *Memory) Load(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return errors.Wrapf(err, "failed to open translation memory %q", path)
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	s.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	line := 0
+	for s.Scan() {
+		line++
+
+		var e Entry
+		if err := json.Unmarshal(s.Bytes(), &e); err != nil {
+			log.Warnf("Skipping broken entry in translation memory %s:%d", path, line)
+			continue
+		}
+
+		m.Add(e.Original, e.Translation)
+	}
+
+	return errors.Wrapf(s.Err(), "failed to read translation memory %q", path)
+}
+
+// Save replaces file with every stored entry
+func (m *Memory) Save(path string) error {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrap(err, "failed to create translation memory directory")
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return errors.Wrap(err, "failed to create temporary translation memory file")
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+
+	for _, e := range m.entries {
+		if err := enc.Encode(e); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "failed to write translation memory")
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return errors.Wrap(os.Rename(tmp.Name(), path), "failed to replace translation memory")
+}
@@ -0,0 +1,128 @@
+package memory
+
+import (
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitgud.io/softashell/rpgmaker-patch-translator/text"
+)
+
+func TestLookup(t *testing.T) {
+	m := New(0.8)
+
+	m.Add("こんにちは、勇者様。\n", "Hello, hero.\n")
+	m.Add("今日はいい天気ですね\n", "Nice weather today\n")
+	m.Add("今日はいい天気ですね\n", "Ignored\n")
+	m.Add("空\n", "\n")
+
+	tests := []struct {
+		input      string
+		want       string
+		similarity float64
+		ok         bool
+	}{
+		{"こんにちは、勇者様。", "Hello, hero.\n", 1, true},
+		{"こんにちは、\n勇者様。", "Hello, hero.\n", 1, true},
+		{"こんにちは、勇者様！", "Hello, hero.\n", 0.9, true},
+		{"今日はいい天気ですね！", "Nice weather today\n", 1 - 1.0/11, true},
+		{"今日は悪い天気", "", 0, false},
+		{"空", "", 0, false},
+	}
+
+	for _, tt := range tests {
+		match, ok := m.Lookup(tt.input)
+		if ok != tt.ok || match.Translation != tt.want || match.Similarity != tt.similarity {
+			t.Errorf("Lookup(%q) = %q (%v), %v, want %q (%v), %v", tt.input, match.Translation, match.Similarity, ok, tt.want, tt.similarity, tt.ok)
+		}
+	}
+
+	exact := New(1)
+	exact.Add("こんにちは、勇者様。", "Hello, hero.")
+
+	if _, ok := exact.Lookup("こんにちは、勇者様！"); ok {
+		t.Error("memory with threshold 1 returned fuzzy match")
+	}
+}
+
+// Index must never skip a candidate that brute force comparison would find
+func TestLookupIndex(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	runes := []rune("あいうえおかきくけこ勇者")
+
+	random := func() string {
+		s := make([]rune, 1+r.Intn(12))
+		for i := range s {
+			s[i] = runes[r.Intn(len(runes))]
+		}
+
+		return string(s)
+	}
+
+	for _, threshold := range []float64{0.5, 0.7, 0.9} {
+		m := New(threshold)
+
+		var originals []string
+		for i := 0; i < 500; i++ {
+			s := random()
+			m.Add(s, s)
+			originals = append(originals, Normalize(s))
+		}
+
+		for i := 0; i < 200; i++ {
+			input := random()
+			if _, ok := m.entries[input]; ok {
+				continue
+			}
+
+			var want float64
+			for _, o := range originals {
+				if s := text.Similarity(input, o); s >= threshold && s > want {
+					want = s
+				}
+			}
+
+			match, ok := m.Lookup(input)
+			if ok != (want > 0) || match.Similarity != want {
+				t.Errorf("threshold %v: Lookup(%q) = %v, %v, want similarity %v", threshold, input, match.Similarity, ok, want)
+			}
+		}
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "memory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "memory.jsonl")
+
+	m := New(1)
+	m.Add("はい", "Yes")
+	m.Add("いいえ", "No")
+
+	if err := m.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := New(1)
+	if err := loaded.Load(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if loaded.Len() != 2 {
+		t.Errorf("loaded %d entries, want 2", loaded.Len())
+	}
+
+	if match, ok := loaded.Lookup("いいえ"); !ok || match.Translation != "No" {
+		t.Errorf("Lookup after load = %q, %v", match.Translation, ok)
+	}
+
+	if err := New(1).Load(filepath.Join(dir, "missing.jsonl")); err != nil {
+		t.Errorf("missing file returned error %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuildMemoryDryRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "memory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	defer func(file string, dry bool) { memoryFile, dryRun = file, dry }(memoryFile, dryRun)
+	memoryFile = filepath.Join(dir, "memory.jsonl")
+
+	tests := []struct {
+		dryRun  bool
+		written bool
+	}{
+		{true, false},
+		{false, true},
+	}
+
+	for _, tt := range tests {
+		dryRun = tt.dryRun
+
+		if _, err := buildMemory(nil); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := os.Stat(memoryFile)
+		if written := err == nil; written != tt.written {
+			t.Errorf("dry run %v: memory file written = %v, want %v", tt.dryRun, written, tt.written)
+		}
+	}
+}
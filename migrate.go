@@ -0,0 +1,330 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gitgud.io/softashell/rpgmaker-patch-translator/memory"
+	"gitgud.io/softashell/rpgmaker-patch-translator/patch"
+	"gitgud.io/softashell/rpgmaker-patch-translator/text"
+	"github.com/pkg/errors"
+)
+
+// migrationSource is a translation from old patch
+type migrationSource struct {
+	original    string
+	translation string
+}
+
+// oldBlock is remembered to report blocks that are gone from new patch
+type oldBlock struct {
+	file        string
+	original    string
+	translation string
+	contexts    []string
+}
+
+// migrationReview is a translation carried over to text that changed since old patch
+type migrationReview struct {
+	file       string
+	context    string
+	old        string
+	new        string
+	translated string
+	similarity float64
+}
+
+// migration carries translations from old patch over to the one regenerated after game update
+type migration struct {
+	threshold float64
+
+	mem      *memory.Memory             // Old translations by original text
+	contexts map[string]migrationSource // Old translations by context
+	blocks   []oldBlock
+
+	// Seen in new patch
+	newOriginals map[string]bool
+	newContexts  map[string]bool
+
+	carried int
+	reviews []migrationReview
+}
+
+func newMigration(threshold float64) *migration {
+	return &migration{
+		threshold:    threshold,
+		mem:          memory.New(threshold),
+		contexts:     make(map[string]migrationSource),
+		newOriginals: make(map[string]bool),
+		newContexts:  make(map[string]bool),
+	}
+}
+
+// load remembers every translation in old patch directory
+func (m *migration) load(dir string) error {
+	if _, err := os.Stat(filepath.Join(dir, "Patch")); err != nil {
+		return errors.Wrap(err, "failed to read old patch")
+	}
+
+	for _, file := range getDirectoryContents(filepath.Join(dir, "Patch")) {
+		pf, err := patch.ReadFile(file, strictPatch)
+		if err != nil {
+			return errors.Wrap(err, "failed to read old patch")
+		}
+
+		name := relativeName(dir, file)
+
+		for _, b := range pf.Blocks {
+			old := oldBlock{file: name, original: b.Original}
+
+			for _, t := range b.Translations {
+				old.contexts = append(old.contexts, t.Contexts...)
+
+				if !t.Translated {
+					continue
+				}
+
+				if len(old.translation) < 1 {
+					old.translation = t.Text
+				}
+
+				m.mem.Add(b.Original, t.Text)
+
+				for _, c := range t.Contexts {
+					m.contexts[c] = migrationSource{b.Original, t.Text}
+				}
+			}
+
+			m.blocks = append(m.blocks, old)
+		}
+	}
+
+	return nil
+}
+
+// find returns old translation for original text in given contexts and how similar old original was
+func (m *migration) find(original string, contexts []string) (migrationSource, float64, bool) {
+	key := memory.Normalize(original)
+
+	// Same text in the same place
+	for _, c := range contexts {
+		if s, ok := m.contexts[c]; ok && memory.Normalize(s.original) == key {
+			return s, 1, true
+		}
+	}
+
+	match, found := m.mem.Lookup(original)
+	if found && match.Similarity >= 1 {
+		return migrationSource{match.Original, match.Translation}, 1, true
+	}
+
+	// Text in the same place changed slightly
+	var best migrationSource
+	var similarity float64
+
+	for _, c := range contexts {
+		s, ok := m.contexts[c]
+		if !ok {
+			continue
+		}
+
+		if sim := text.Similarity(key, memory.Normalize(s.original)); sim >= m.threshold && sim > similarity {
+			best, similarity = s, sim
+		}
+	}
+
+	if found && match.Similarity > similarity {
+		best, similarity = migrationSource{match.Original, match.Translation}, match.Similarity
+	}
+
+	return best, similarity, similarity > 0
+}
+
+// migrateFile fills untranslated blocks of a file in new patch, it's only written if something changed
+func (m *migration) migrateFile(dir, file string) error {
+	pf, err := patch.ReadFile(file, strictPatch)
+	if err != nil {
+		return err
+	}
+
+	logDiagnostics(pf.Diagnostics)
+
+	if patch.HasErrors(pf.Diagnostics) {
+		return errors.Errorf("%q has errors, check it with validate command", file)
+	}
+
+	name := relativeName(dir, file)
+
+	var changed bool
+
+	for _, b := range pf.Blocks {
+		m.newOriginals[memory.Normalize(b.Original)] = true
+
+		for i, t := range b.Translations {
+			for _, c := range t.Contexts {
+				m.newContexts[c] = true
+			}
+
+			if t.Translated {
+				continue
+			}
+
+			s, similarity, ok := m.find(b.Original, t.Contexts)
+			if !ok {
+				continue
+			}
+
+			// Old translation already has line breaks
+			t.Text = s.translation
+			t.Translated = true
+
+			b.Translations[i] = t
+
+			m.carried++
+			changed = true
+
+			if similarity < 1 {
+				m.reviews = append(m.reviews, migrationReview{
+					file:       name,
+					context:    contextNames(t.Contexts),
+					old:        s.original,
+					new:        b.Original,
+					translated: s.translation,
+					similarity: similarity,
+				})
+			}
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	if err := backupPatch(); err != nil {
+		return err
+	}
+
+	return patch.WriteFile(pf, nil)
+}
+
+// disappeared returns old blocks whose text and contexts are both missing from new patch
+func (m *migration) disappeared() []oldBlock {
+	var gone []oldBlock
+
+	for _, b := range m.blocks {
+		if m.newOriginals[memory.Normalize(b.original)] {
+			continue
+		}
+
+		var found bool
+
+		for _, c := range b.contexts {
+			if m.newContexts[c] {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			gone = append(gone, b)
+		}
+	}
+
+	return gone
+}
+
+// report lists translations to review and blocks that disappeared
+func (m *migration) report(gone []oldBlock) string {
+	var out string
+
+	if len(m.reviews) > 0 {
+		out += "Original text changed, check these translations:\n\n"
+
+		for _, r := range m.reviews {
+			out += fmt.Sprintf("%s: %s (%.0f%%)\nOld: %q\nNew: %q\nTranslation: %q\n\n", r.file, r.context, r.similarity*100, r.old, r.new, r.translated)
+		}
+	}
+
+	if len(gone) > 0 {
+		out += "Blocks missing from new patch:\n\n"
+
+		for _, b := range gone {
+			out += fmt.Sprintf("%s: %s\nOriginal: %q\nTranslation: %q\n\n", b.file, contextNames(b.contexts), b.original, b.translation)
+		}
+	}
+
+	return out
+}
+
+func contextNames(contexts []string) string {
+	names := make([]string, len(contexts))
+	for i, c := range contexts {
+		names[i] = strings.TrimPrefix(c, ": ")
+	}
+
+	return strings.Join(names, ", ")
+}
+
+func relativeName(dir, file string) string {
+	if rel, err := filepath.Rel(filepath.Join(dir, "Patch"), file); err == nil {
+		return filepath.ToSlash(rel)
+	}
+
+	return file
+}
+
+// migrateCommand carries translations from old patch directory over to the new one generated after game update
+func migrateCommand(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+
+	var threshold float64
+	var reportFile string
+
+	fs.Float64Var(&threshold, "threshold", 0.8, "Min similarity of changed text to carry its translation over, 1 only allows exact matches")
+	fs.StringVar(&reportFile, "report", "migrate.txt", "File listing translations to review and blocks that disappeared")
+
+	fs.Parse(args)
+
+	if fs.NArg() < 2 {
+		return fmt.Errorf("migrate command requires old and new patch directories as arguments")
+	}
+
+	oldDir, newDir := fs.Arg(0), fs.Arg(1)
+
+	if err := checkPatchVersion(newDir); err != nil {
+		return err
+	}
+
+	m := newMigration(threshold)
+
+	if err := m.load(oldDir); err != nil {
+		return err
+	}
+
+	// Backup is made in new patch directory before the first file is changed
+	patchDir = newDir
+
+	for _, file := range getDirectoryContents(filepath.Join(newDir, "Patch")) {
+		if err := m.migrateFile(newDir, file); err != nil {
+			return err
+		}
+	}
+
+	gone := m.disappeared()
+
+	fmt.Printf("Carried over %d translations, %d of them need review, %d blocks disappeared\n", m.carried, len(m.reviews), len(gone))
+
+	if report := m.report(gone); len(report) > 0 {
+		if err := ioutil.WriteFile(reportFile, []byte(report), 0644); err != nil {
+			return err
+		}
+
+		fmt.Printf("See %s for details\n", reportFile)
+	}
+
+	return nil
+}
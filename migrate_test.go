@@ -0,0 +1,109 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gitgud.io/softashell/rpgmaker-patch-translator/patch"
+)
+
+func writePatch(t *testing.T, dir, content string) {
+	err := os.MkdirAll(filepath.Join(dir, "Patch"), 0755)
+	check(err)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "RPGMKTRANSPATCH"), []byte("> RPGMAKER TRANS PATCH V3\n"), 0644)
+	check(err)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "Patch", "Map001.txt"), []byte(content), 0644)
+	check(err)
+}
+
+func TestMigrate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrate")
+	check(err)
+	defer os.RemoveAll(dir)
+
+	defer func(dir string, enabled bool) {
+		patchDir, backupEnabled = dir, enabled
+	}(patchDir, backupEnabled)
+
+	backupEnabled = false
+
+	oldDir, newDir := filepath.Join(dir, "old"), filepath.Join(dir, "new")
+
+	writePatch(t, oldDir, `> RPGMAKER TRANS PATCH FILE VERSION 3.2
+> BEGIN STRING
+こんにちは
+> CONTEXT: Map001/1/1/Dialogue/0
+Hello
+> END STRING
+
+> BEGIN STRING
+勇者が来た、みんな逃げろ
+> CONTEXT: Map001/1/1/Dialogue/1
+The hero is here, everyone run
+> END STRING
+
+> BEGIN STRING
+さようなら
+> CONTEXT: Map001/1/1/Dialogue/2
+Goodbye
+> END STRING
+`)
+
+	writePatch(t, newDir, `> RPGMAKER TRANS PATCH FILE VERSION 3.2
+> BEGIN STRING
+こんにちは
+> CONTEXT: Map001/2/1/Dialogue/0 < UNTRANSLATED
+
+> END STRING
+
+> BEGIN STRING
+勇者が来た、みんな逃げて
+> CONTEXT: Map001/1/1/Dialogue/1 < UNTRANSLATED
+
+> END STRING
+
+> BEGIN STRING
+新しい
+> CONTEXT: Map001/1/1/Dialogue/3 < UNTRANSLATED
+
+> END STRING
+`)
+
+	report := filepath.Join(dir, "migrate.txt")
+
+	err = migrateCommand([]string{"-report", report, oldDir, newDir})
+	check(err)
+
+	pf, err := patch.ReadFile(filepath.Join(newDir, "Patch", "Map001.txt"), false)
+	check(err)
+
+	want := []string{"Hello\n", "The hero is here, everyone run\n", ""}
+
+	for i, b := range pf.Blocks {
+		if tl := b.Translations[0]; tl.Text != want[i] || tl.Translated != (len(want[i]) > 0) {
+			t.Errorf("block %d translation = %q (translated %v), want %q", i, tl.Text, tl.Translated, want[i])
+		}
+	}
+
+	data, err := ioutil.ReadFile(report)
+	check(err)
+
+	for _, s := range []string{"Map001/1/1/Dialogue/1", "Original: \"さようなら\\n\""} {
+		if !strings.Contains(string(data), s) {
+			t.Errorf("report doesn't mention %q:\n%s", s, data)
+		}
+	}
+
+	if strings.Contains(string(data), "Dialogue/0") {
+		t.Errorf("block moved to another context shouldn't be reported:\n%s", data)
+	}
+
+	if err := migrateCommand([]string{"-report", report, filepath.Join(dir, "missing"), newDir}); err == nil {
+		t.Error("expected error for missing old patch directory")
+	}
+}
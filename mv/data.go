@@ -0,0 +1,237 @@
+package mv
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gitgud.io/softashell/rpgmaker-patch-translator/patch"
+)
+
+// Event command codes that contain text
+const (
+	codeText       = 401
+	codeChoices    = 102
+	codeScrollText = 405
+)
+
+// Database files and their text fields, contexts end with "//" for multi line fields
+// the same way rpgmaker-trans names them
+var databaseFields = map[string][]string{
+	"Actors":  {"name/", "nickname/", "profile//"},
+	"Armors":  {"name/", "description//"},
+	"Classes": {"name/"},
+	"Enemies": {"name/"},
+	"Items":   {"name/", "description//"},
+	"Skills":  {"name/", "description//", "message1/", "message2/"},
+	"States":  {"name/", "message1/", "message2/", "message3/", "message4/"},
+	"Weapons": {"name/", "description//"},
+}
+
+// Lists in System.json
+var systemLists = []string{"elements", "skillTypes", "weaponTypes", "armorTypes", "equipTypes"}
+
+var mapFile = regexp.MustCompile(`^Map\d+$`)
+
+// IsDataFile reports if file contains text that can be translated
+func IsDataFile(path string) bool {
+	if filepath.Ext(path) != ".json" {
+		return false
+	}
+
+	name := strings.TrimSuffix(filepath.Base(path), ".json")
+
+	if _, ok := databaseFields[name]; ok {
+		return true
+	}
+
+	switch name {
+	case "CommonEvents", "Troops", "System":
+		return true
+	}
+
+	return mapFile.MatchString(name)
+}
+
+// extractor collects text from data file into blocks, same text in one file is a single block
+type extractor struct {
+	blocks    []patch.PatchBlock
+	byText    map[string]int
+	locations map[string][]*value // String values behind each context
+	groups    map[string]group    // Text commands behind dialogue and scrolling text contexts
+}
+
+// group is a run of text commands, lines that don't fit are added as copies of the last command
+type group struct {
+	last *value
+	prev *value // Item before the last command, nil if it's the first one in the list
+}
+
+func newExtractor() *extractor {
+	return &extractor{
+		byText:    make(map[string]int),
+		locations: make(map[string][]*value),
+		groups:    make(map[string]group),
+	}
+}
+
+// add remembers text found in values under context, values are joined with line breaks
+func (e *extractor) add(context string, values ...*value) {
+	var lines []string
+	for _, v := range values {
+		lines = append(lines, v.str)
+	}
+
+	text := strings.Join(lines, "\n")
+	if len(strings.TrimSpace(text)) < 1 {
+		return
+	}
+
+	// Patch text always ends with a line break
+	text += "\n"
+
+	i, ok := e.byText[text]
+	if !ok {
+		i = len(e.blocks)
+		e.byText[text] = i
+
+		e.blocks = append(e.blocks, patch.PatchBlock{
+			Original:     text,
+			Translations: []patch.TranslationBlock{{}},
+		})
+	}
+
+	t := &e.blocks[i].Translations[0]
+	t.Contexts = append(t.Contexts, context)
+
+	e.locations[context] = values
+}
+
+// file extracts text of data file with given name, without extension
+func (e *extractor) file(name string, root *value) {
+	if fields, ok := databaseFields[name]; ok {
+		for _, item := range root.elems() {
+			for _, f := range fields {
+				if v := item.field(strings.TrimRight(f, "/")); v.isString() {
+					e.add(fmt.Sprintf(": %s/%d/%s", name, item.field("id").int(), f), v)
+				}
+			}
+		}
+
+		return
+	}
+
+	switch {
+	case name == "System":
+		e.system(root)
+	case name == "CommonEvents":
+		for _, item := range root.elems() {
+			e.commands(fmt.Sprintf(": Commonevents/%d", item.field("id").int()), item.field("list"))
+		}
+	case name == "Troops":
+		for _, item := range root.elems() {
+			id := item.field("id").int()
+
+			if v := item.field("name"); v.isString() {
+				e.add(fmt.Sprintf(": Troops/%d/name/", id), v)
+			}
+
+			for p, page := range item.field("pages").elems() {
+				e.commands(fmt.Sprintf(": Troops/%d/%d", id, p+1), page.field("list"))
+			}
+		}
+	case mapFile.MatchString(name):
+		if v := root.field("displayName"); v.isString() {
+			e.add(fmt.Sprintf(": %s/display_name/", name), v)
+		}
+
+		for _, event := range root.field("events").elems() {
+			for p, page := range event.field("pages").elems() {
+				e.commands(fmt.Sprintf(": %s/%d/%d", name, event.field("id").int(), p+1), page.field("list"))
+			}
+		}
+	}
+}
+
+func (e *extractor) system(root *value) {
+	if v := root.field("gameTitle"); v.isString() {
+		e.add(": System/game_title/", v)
+	}
+
+	if v := root.field("currencyUnit"); v.isString() {
+		e.add(": System/currency_unit/", v)
+	}
+
+	for _, name := range systemLists {
+		e.list(": System/"+name, root.field(name))
+	}
+
+	terms := root.field("terms")
+
+	for _, name := range []string{"basic", "commands", "params"} {
+		e.list(": System/terms/"+name, terms.field(name))
+	}
+
+	messages := terms.field("messages")
+	if messages == nil {
+		return
+	}
+
+	for _, key := range messages.keys {
+		if v := messages.field(key); v.isString() {
+			e.add(fmt.Sprintf(": System/terms/messages/%s/", key), v)
+		}
+	}
+}
+
+func (e *extractor) list(prefix string, list *value) {
+	for i, v := range list.elems() {
+		if v.isString() {
+			e.add(fmt.Sprintf("%s/%d/", prefix, i), v)
+		}
+	}
+}
+
+// commands extracts text from event command list, consecutive text lines are one block
+// with context of the first line, like ": Map001/1/1/Dialogue/3"
+func (e *extractor) commands(prefix string, list *value) {
+	items := list.elems()
+
+	for i := 0; i < len(items); i++ {
+		cmd := items[i]
+		code := cmd.field("code").int()
+
+		switch code {
+		case codeText, codeScrollText:
+			var lines []*value
+
+			j := i
+			for ; j < len(items) && items[j].field("code").int() == code; j++ {
+				if v := items[j].field("parameters").index(0); v.isString() {
+					lines = append(lines, v)
+				}
+			}
+
+			kind := "Dialogue"
+			if code == codeScrollText {
+				kind = "ScrollText"
+			}
+
+			if len(lines) > 0 {
+				context := fmt.Sprintf("%s/%s/%d", prefix, kind, i)
+
+				e.add(context, lines...)
+				e.groups[context] = group{last: items[j-1], prev: list.index(j - 2)}
+			}
+
+			i = j - 1
+		case codeChoices:
+			for n, v := range cmd.field("parameters").index(0).elems() {
+				if v.isString() {
+					e.add(fmt.Sprintf("%s/%d/Choice/%d", prefix, i, n), v)
+				}
+			}
+		}
+	}
+}
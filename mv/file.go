@@ -0,0 +1,221 @@
+// Package mv reads text from RPG Maker MV and MZ data/*.json files as patch blocks
+// and writes translations back into them
+package mv
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gitgud.io/softashell/rpgmaker-patch-translator/patch"
+	"gitgud.io/softashell/rpgmaker-patch-translator/text"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// DataDir returns data directory of MV or MZ game relative to dir, it's empty if there isn't one
+func DataDir(dir string) string {
+	for _, d := range []string{"data", filepath.Join("www", "data")} {
+		if _, err := os.Stat(filepath.Join(dir, d, "System.json")); err == nil {
+			return d
+		}
+	}
+
+	return ""
+}
+
+// load parses data file and extracts its text
+func load(path string) ([]byte, *extractor, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to open data file: %q", path)
+	}
+
+	root, err := parse(data)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to parse %q", path)
+	}
+
+	e := newExtractor()
+	e.file(strings.TrimSuffix(filepath.Base(path), ".json"), root)
+
+	return data, e, nil
+}
+
+// ReadFile returns text of data file as untranslated patch blocks with contexts named like rpgmaker-trans does
+func ReadFile(path string) (patch.File, error) {
+	log.Debugf("Parsing %q", filepath.Base(path))
+
+	_, e, err := load(path)
+	if err != nil {
+		return patch.File{Path: path}, err
+	}
+
+	return patch.File{Path: path, Blocks: e.blocks}, nil
+}
+
+// replacement changes data between start and end, insertions have start equal to end
+type replacement struct {
+	start int
+	end   int
+	data  []byte
+}
+
+// WriteFile writes translated blocks of data file src into file.Path, everything
+// except translated strings is kept as it was. Lines of dialogue that don't fit
+// in original commands are added as new commands with the same code and indent
+func WriteFile(src string, file patch.File, lb patch.LineBreaker) error {
+	log.Debugf("Writing %s", file.Path)
+
+	data, e, err := load(src)
+	if err != nil {
+		return err
+	}
+
+	var replacements []replacement
+
+	for _, b := range file.Blocks {
+		for _, t := range b.Translations {
+			if !t.Translated {
+				continue
+			}
+
+			tl := t.Text
+			if t.Touched && lb != nil && patch.ShouldBreakLines(t.Contexts) {
+				tl = text.Unescape(lb(text.Escape(tl)))
+			}
+
+			lines := strings.Split(strings.TrimRight(tl, "\n"), "\n")
+
+			for _, c := range t.Contexts {
+				r, err := replaceLines(data, e.locations[c], e.groups[c], lines)
+				if err != nil {
+					return errors.Wrapf(err, "failed to write %q", file.Path)
+				}
+
+				replacements = append(replacements, r...)
+			}
+		}
+	}
+
+	return writeAtomic(file.Path, splice(data, replacements))
+}
+
+// replaceLines puts lines into string values, extra lines are added as copies of the last text
+// command in group or joined into the last value if there are no commands to copy
+func replaceLines(data []byte, values []*value, g group, lines []string) ([]replacement, error) {
+	var replacements []replacement
+
+	param := g.last.field("parameters").index(0)
+	insert := param.isString()
+
+	for i, v := range values {
+		var line string
+
+		switch {
+		case i == len(values)-1 && i < len(lines) && !insert:
+			line = strings.Join(lines[i:], "\n")
+		case i < len(lines):
+			line = lines[i]
+		}
+
+		quoted, err := quote(line)
+		if err != nil {
+			return nil, err
+		}
+
+		replacements = append(replacements, replacement{v.start, v.end, quoted})
+	}
+
+	if !insert || len(lines) <= len(values) {
+		return replacements, nil
+	}
+
+	// Commands are separated the same way as the last two in the list
+	sep := []byte(",")
+	if g.prev != nil {
+		sep = data[g.prev.end:g.last.start]
+	}
+
+	var commands []byte
+
+	for _, line := range lines[len(values):] {
+		quoted, err := quote(line)
+		if err != nil {
+			return nil, err
+		}
+
+		commands = append(commands, sep...)
+		commands = append(commands, data[g.last.start:param.start]...)
+		commands = append(commands, quoted...)
+		commands = append(commands, data[param.end:g.last.end]...)
+	}
+
+	return append(replacements, replacement{g.last.end, g.last.end, commands}), nil
+}
+
+// splice applies replacements to data
+func splice(data []byte, replacements []replacement) []byte {
+	sort.SliceStable(replacements, func(i, j int) bool {
+		return replacements[i].start < replacements[j].start
+	})
+
+	var out []byte
+
+	pos := 0
+	inserted := make(map[int]bool)
+
+	for _, r := range replacements {
+		// Same value under several contexts
+		if r.start < pos || (r.start == r.end && inserted[r.start]) {
+			continue
+		}
+
+		if r.start == r.end {
+			inserted[r.start] = true
+		}
+
+		out = append(out, data[pos:r.start]...)
+		out = append(out, r.data...)
+
+		pos = r.end
+	}
+
+	return append(out, data[pos:]...)
+}
+
+// writeAtomic replaces file through a temporary one so it's never left half written
+func writeAtomic(path string, data []byte) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return errors.Wrapf(err, "failed to create temporary file for %q", path)
+	}
+
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	if err := f.Chmod(mode); err != nil {
+		return err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		return errors.Wrapf(err, "failed to write %q", path)
+	}
+
+	if err := f.Sync(); err != nil {
+		return errors.Wrapf(err, "failed to sync %q", path)
+	}
+
+	if err := f.Close(); err != nil {
+		return errors.Wrapf(err, "failed to write %q", path)
+	}
+
+	return errors.Wrapf(os.Rename(f.Name(), path), "failed to replace %q", path)
+}
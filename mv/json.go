@@ -0,0 +1,203 @@
+package mv
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// value is a parsed JSON value that remembers where it is in the file, so strings
+// can be replaced without touching anything else
+type value struct {
+	kind  byte // '{', '[', '"' or 0 for numbers, booleans and null
+	start int  // Span in the file, quotes are included for strings
+	end   int
+
+	str    string // Decoded string or raw literal
+	keys   []string
+	fields map[string]*value
+	items  []*value
+}
+
+func (v *value) field(name string) *value {
+	if v == nil || v.kind != '{' {
+		return nil
+	}
+
+	return v.fields[name]
+}
+
+func (v *value) index(i int) *value {
+	if v == nil || v.kind != '[' || i < 0 || i >= len(v.items) {
+		return nil
+	}
+
+	return v.items[i]
+}
+
+// elems returns array items, nil for anything else
+func (v *value) elems() []*value {
+	if v == nil || v.kind != '[' {
+		return nil
+	}
+
+	return v.items
+}
+
+func (v *value) isString() bool {
+	return v != nil && v.kind == '"'
+}
+
+func (v *value) int() int {
+	if v == nil || v.kind != 0 {
+		return 0
+	}
+
+	n, _ := strconv.Atoi(v.str)
+
+	return n
+}
+
+// parser builds value tree from JSON that's already known to be valid
+type parser struct {
+	data []byte
+	pos  int
+}
+
+func parse(data []byte) (*value, error) {
+	if !json.Valid(data) {
+		// Let encoding/json describe what's wrong
+		var v interface{}
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("invalid JSON")
+	}
+
+	p := &parser{data: data}
+
+	return p.value()
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.data) {
+		switch p.data[p.pos] {
+		case ' ', '\t', '\r', '\n':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (p *parser) value() (*value, error) {
+	p.skipSpace()
+
+	v := &value{start: p.pos}
+
+	switch p.data[p.pos] {
+	case '{':
+		v.kind = '{'
+		v.fields = make(map[string]*value)
+
+		p.pos++
+
+		for {
+			p.skipSpace()
+
+			if p.data[p.pos] == '}' {
+				p.pos++
+				break
+			}
+
+			if p.data[p.pos] == ',' {
+				p.pos++
+				p.skipSpace()
+			}
+
+			key, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+
+			p.skipSpace()
+			p.pos++ // Colon
+
+			item, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+
+			v.keys = append(v.keys, key.str)
+			v.fields[key.str] = item
+		}
+	case '[':
+		v.kind = '['
+
+		p.pos++
+
+		for {
+			p.skipSpace()
+
+			if p.data[p.pos] == ']' {
+				p.pos++
+				break
+			}
+
+			if p.data[p.pos] == ',' {
+				p.pos++
+			}
+
+			item, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+
+			v.items = append(v.items, item)
+		}
+	case '"':
+		v.kind = '"'
+
+		p.pos++
+
+		for p.data[p.pos] != '"' {
+			if p.data[p.pos] == '\\' {
+				p.pos++
+			}
+
+			p.pos++
+		}
+
+		p.pos++
+
+		if err := json.Unmarshal(p.data[v.start:p.pos], &v.str); err != nil {
+			return nil, err
+		}
+	default:
+		for p.pos < len(p.data) && bytes.IndexByte([]byte(",]} \t\r\n"), p.data[p.pos]) == -1 {
+			p.pos++
+		}
+
+		v.str = string(p.data[v.start:p.pos])
+	}
+
+	v.end = p.pos
+
+	return v, nil
+}
+
+// quote encodes string the way RPG Maker does, without escaping HTML characters
+func quote(s string) ([]byte, error) {
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+
+	if err := enc.Encode(s); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
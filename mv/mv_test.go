@@ -0,0 +1,181 @@
+package mv
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gitgud.io/softashell/rpgmaker-patch-translator/patch"
+)
+
+var dataDir = filepath.Join("..", "testdata", "mv", "data")
+
+// contexts returns original text of every block by its contexts
+func contexts(file patch.File) map[string]string {
+	m := make(map[string]string)
+
+	for _, b := range file.Blocks {
+		for _, t := range b.Translations {
+			for _, c := range t.Contexts {
+				m[c] = b.Original
+			}
+		}
+	}
+
+	return m
+}
+
+func TestReadFile(t *testing.T) {
+	tests := []struct {
+		file string
+		want map[string]string
+	}{
+		{"Map001.json", map[string]string{
+			": Map001/display_name/":  "始まりの村\n",
+			": Map001/1/1/Dialogue/1": "こんにちは\n\\C[2]勇者\\C[0]が来た\n",
+			": Map001/1/1/3/Choice/0": "はい\n",
+			": Map001/1/1/3/Choice/1": "いいえ\n",
+			": Map001/2/1/Dialogue/1": "こんにちは\n",
+		}},
+		{"Actors.json", map[string]string{
+			": Actors/1/name/":     "ハロルド\n",
+			": Actors/1/profile//": "勇者の一人。\n旅をしている。\n",
+		}},
+		{"System.json", map[string]string{
+			": System/game_title/":                   "テストゲーム\n",
+			": System/currency_unit/":                "G\n",
+			": System/armorTypes/1/":                 "一般防具\n",
+			": System/armorTypes/2/":                 "魔法防具\n",
+			": System/elements/1/":                   "物理\n",
+			": System/elements/2/":                   "炎\n",
+			": System/equipTypes/1/":                 "武器\n",
+			": System/equipTypes/2/":                 "盾\n",
+			": System/skillTypes/1/":                 "魔法\n",
+			": System/weaponTypes/1/":                "剣\n",
+			": System/terms/basic/0/":                "レベル\n",
+			": System/terms/basic/1/":                "Lv\n",
+			": System/terms/commands/0/":             "戦う\n",
+			": System/terms/commands/1/":             "逃げる\n",
+			": System/terms/params/0/":               "最大HP\n",
+			": System/terms/messages/actionFailure/": "%1には効かなかった！\n",
+			": System/terms/messages/alwaysDash/":    "常時ダッシュ\n",
+		}},
+	}
+
+	for _, tt := range tests {
+		file, err := ReadFile(filepath.Join(dataDir, tt.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := contexts(file); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: contexts = %q, want %q", tt.file, got, tt.want)
+		}
+	}
+
+	// Consecutive dialogue lines are a single block
+	file, _ := ReadFile(filepath.Join(dataDir, "Map001.json"))
+	if len(file.Blocks) != 5 {
+		t.Errorf("expected 5 blocks, got %d", len(file.Blocks))
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dataDir, "Map001.json")
+
+	original, err := ioutil.ReadFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := ReadFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing translated keeps file as it was
+	file.Path = filepath.Join(dir, "Map001.json")
+
+	if err := WriteFile(src, file, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := ioutil.ReadFile(file.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(out, original) {
+		t.Error("untranslated file was changed")
+	}
+
+	translations := map[string]string{
+		"こんにちは\n\\C[2]勇者\\C[0]が来た\n": "Hello\n\\C[2]The hero\\C[0]\nhas come\n",
+		"はい\n":  "Yes\n",
+		"いいえ\n": "No <\"quoted\">\n",
+	}
+
+	for i, b := range file.Blocks {
+		if tl, ok := translations[b.Original]; ok {
+			file.Blocks[i].Translations[0].Text = tl
+			file.Blocks[i].Translations[0].Translated = true
+		}
+	}
+
+	if err := WriteFile(src, file, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err = ioutil.ReadFile(file.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var data struct {
+		Events []*struct {
+			Pages []struct {
+				List []struct {
+					Code       int
+					Indent     int
+					Parameters []interface{}
+				}
+			}
+		}
+	}
+
+	if err := json.Unmarshal(out, &data); err != nil {
+		t.Fatalf("output isn't valid JSON: %v", err)
+	}
+
+	list := data.Events[1].Pages[0].List
+
+	// Lines that don't fit in original commands are added as new commands
+	for i, want := range []string{"Hello", "\\C[2]The hero\\C[0]", "has come"} {
+		if c := list[1+i]; c.Code != 401 || c.Indent != list[1].Indent || c.Parameters[0] != want {
+			t.Errorf("dialogue line %d = %d %q, want 401 %q", i, c.Code, c.Parameters[0], want)
+		}
+	}
+
+	choices := list[4].Parameters[0].([]interface{})
+	if choices[0] != "Yes" || choices[1] != "No <\"quoted\">" {
+		t.Errorf("unexpected choices %q", choices)
+	}
+
+	// Untranslated text and everything around strings is kept
+	for _, s := range []string{`"displayName":"始まりの村","width":17,`, `{"code":401,"indent":0,"parameters":["こんにちは"]}`, `"No <\"quoted\">"`} {
+		if !strings.Contains(string(out), s) {
+			t.Errorf("output doesn't contain %s", s)
+		}
+	}
+}
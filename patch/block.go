@@ -0,0 +1,48 @@
+package patch
+
+import (
+	"strings"
+
+	"gitgud.io/softashell/rpgmaker-patch-translator/engine"
+)
+
+type PatchBlock struct {
+	Original     string
+	Translations []TranslationBlock
+
+	Before     []string    // Unknown directives between previous block and this one
+	Directives []Directive // Unknown directives inside original text
+
+	Preceding []string // Dialogue lines before this one, used as translation context and not saved in patch
+}
+
+type TranslationBlock struct {
+	Contexts   []string
+	Text       string
+	Touched    bool
+	Translated bool
+
+	Directives []Directive // Unknown directives after contexts or inside translation text
+}
+
+// Directive is a "> " line that isn't understood, it's kept so the patch can be written back unchanged
+type Directive struct {
+	Text string // Line without "> " prefix
+	Line int    // Number of text lines before it
+}
+
+func ShouldBreakLines(contexts []string) bool {
+	for _, c := range contexts {
+		if engine.Is(engine.RPGMVX) || engine.Is(engine.MV) {
+			if strings.Contains(c, "GameINI/Title") || strings.Contains(c, "System/game_title/") {
+				return false
+			}
+		} else if engine.Is(engine.Wolf) {
+			if strings.HasPrefix(c, " GAMEDAT:") {
+				return false
+			}
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,51 @@
+package patch
+
+import "fmt"
+
+// Severity tells if Diagnostic is something to look at or a file that can't be used as it is
+type Severity int
+
+const (
+	Warning Severity = iota
+	Error
+)
+
+func (s Severity) String() string {
+	if s == Error {
+		return "error"
+	}
+
+	return "warning"
+}
+
+// Diagnostic is a problem found while reading patch file
+type Diagnostic struct {
+	Path     string // Set by ReadFile
+	Line     int    // 0 when problem is with the whole file
+	Severity Severity
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	location := d.Path
+	if d.Line > 0 {
+		location = fmt.Sprintf("%s:%d", location, d.Line)
+	}
+
+	if len(location) < 1 {
+		return fmt.Sprintf("%s: %s", d.Severity, d.Message)
+	}
+
+	return fmt.Sprintf("%s: %s: %s", location, d.Severity, d.Message)
+}
+
+// HasErrors reports if any of diagnostics is an error
+func HasErrors(diagnostics []Diagnostic) bool {
+	for _, d := range diagnostics {
+		if d.Severity == Error {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,175 @@
+package patch
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"gitgud.io/softashell/rpgmaker-patch-translator/text"
+)
+
+// CopyBlocks returns blocks that can be changed without affecting the originals
+func CopyBlocks(blocks []PatchBlock) []PatchBlock {
+	c := make([]PatchBlock, len(blocks))
+
+	for i, b := range blocks {
+		b.Translations = append([]TranslationBlock(nil), b.Translations...)
+		c[i] = b
+	}
+
+	return c
+}
+
+// Diff writes unified diff between old and new version of the same file, every changed block is
+// a separate hunk with the whole block as context. Nothing is written if blocks are the same.
+// Line numbers match files written by Writer, name is used in file headers
+func Diff(w io.Writer, name string, old, new File, lb LineBreaker) error {
+	if len(old.Blocks) != len(new.Blocks) {
+		return fmt.Errorf("can't compare %q, number of blocks changed from %d to %d", name, len(old.Blocks), len(new.Blocks))
+	}
+
+	var out bytes.Buffer
+
+	// Version line is followed by the first block
+	oldLine, newLine := 2, 2
+
+	for i := range old.Blocks {
+		a, err := blockLines(old.Blocks[i], nil)
+		if err != nil {
+			return err
+		}
+
+		b, err := blockLines(new.Blocks[i], lb)
+		if err != nil {
+			return err
+		}
+
+		if !equalLines(a, b) {
+			if out.Len() < 1 {
+				fmt.Fprintf(&out, "--- a/%s\n+++ b/%s\n", name, name)
+			}
+
+			fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@%s\n", oldLine, len(a), newLine, len(b), hunkHeader(new.Blocks[i], lb))
+
+			for _, l := range diffLines(a, b) {
+				out.WriteString(l + "\n")
+			}
+		}
+
+		// Blocks are separated by an empty line
+		oldLine += len(a) + 1
+		newLine += len(b) + 1
+	}
+
+	_, err := w.Write(out.Bytes())
+
+	return err
+}
+
+// blockLines returns lines of block as Writer writes them
+func blockLines(b PatchBlock, lb LineBreaker) ([]string, error) {
+	var buf bytes.Buffer
+
+	w := NewWriter(&buf)
+	w.LineBreaker = lb
+
+	if err := w.Write(b); err != nil {
+		return nil, err
+	}
+
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+
+	return strings.Split(buf.String(), "\n"), nil
+}
+
+// hunkHeader describes changed block by its first context and tells if line breaks were added to new translation
+func hunkHeader(b PatchBlock, lb LineBreaker) string {
+	var header string
+	var broken bool
+
+	for _, t := range b.Translations {
+		if len(header) < 1 && len(t.Contexts) > 0 {
+			header = " " + strings.TrimPrefix(t.Contexts[0], ": ")
+		}
+
+		if t.Translated && t.Touched && lb != nil && ShouldBreakLines(t.Contexts) {
+			trans := text.Escape(t.Text)
+			if lb(trans) != trans {
+				broken = true
+			}
+		}
+	}
+
+	if broken {
+		header += " (line breaks added)"
+	}
+
+	return header
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// diffLines returns lines of a and b prefixed with " ", "-" or "+" based on their longest common subsequence
+func diffLines(a, b []string) []string {
+	// lcs[i][j] is length of common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var lines []string
+
+	i, j := 0, 0
+
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, " "+a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, "-"+a[i])
+			i++
+		default:
+			lines = append(lines, "+"+b[j])
+			j++
+		}
+	}
+
+	for ; i < len(a); i++ {
+		lines = append(lines, "-"+a[i])
+	}
+
+	for ; j < len(b); j++ {
+		lines = append(lines, "+"+b[j])
+	}
+
+	return lines
+}
@@ -0,0 +1,124 @@
+package patch
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// File is a whole patch file loaded in memory
+type File struct {
+	Path    string
+	Version string
+	Format  Format
+	Blocks  []PatchBlock
+
+	Diagnostics []Diagnostic // Problems found while reading the file
+}
+
+// ReadFile reads every block of patch file, blocks read so far are returned along with any error.
+// Strict makes unknown directives an error
+func ReadFile(path string, strict bool) (File, error) {
+	log.Debugf("Parsing %q", filepath.Base(path))
+
+	file := File{Path: path, Format: DefaultFormat}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return file, errors.Wrapf(err, "failed to open patch file: %q", path)
+	}
+	defer f.Close()
+
+	r := NewReader(f)
+	r.Strict = strict
+
+	for {
+		b, err := r.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			file.read(r)
+
+			return file, errors.Wrapf(err, "failed to parse %q", path)
+		}
+
+		file.Blocks = append(file.Blocks, b)
+	}
+
+	file.read(r)
+
+	return file, nil
+}
+
+// read copies what reader found about the whole file
+func (file *File) read(r *Reader) {
+	file.Version = r.Version()
+	file.Format = r.Format()
+
+	for _, d := range r.Diagnostics() {
+		d.Path = file.Path
+		file.Diagnostics = append(file.Diagnostics, d)
+	}
+}
+
+// WriteFile replaces file at file.Path, lb is applied to new translations that need line breaks.
+// File is written to a temporary file first so original is never left half written
+func WriteFile(file File, lb LineBreaker) error {
+	log.Debugf("Writing %s", file.Path)
+
+	f, err := ioutil.TempFile(filepath.Dir(file.Path), "."+filepath.Base(file.Path)+".tmp")
+	if err != nil {
+		return errors.Wrapf(err, "failed to create temporary file for %q", file.Path)
+	}
+
+	// Removes temporary file if anything fails, it's gone already after rename
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(file.Path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	if err := f.Chmod(mode); err != nil {
+		return err
+	}
+
+	w := NewWriter(f)
+	w.Format = file.Format
+	w.LineBreaker = lb
+
+	if err := w.WriteVersion(file.Version); err != nil {
+		return errors.Wrapf(err, "failed to write %q", file.Path)
+	}
+
+	for _, b := range file.Blocks {
+		if err := w.Write(b); err != nil {
+			return errors.Wrapf(err, "failed to write %q", file.Path)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return errors.Wrapf(err, "failed to write %q", file.Path)
+	}
+
+	if err := f.Sync(); err != nil {
+		return errors.Wrapf(err, "failed to sync %q", file.Path)
+	}
+
+	if err := f.Close(); err != nil {
+		return errors.Wrapf(err, "failed to write %q", file.Path)
+	}
+
+	if err := os.Rename(f.Name(), file.Path); err != nil {
+		return errors.Wrapf(err, "failed to replace %q", file.Path)
+	}
+
+	log.Debugf("Done writing %s", file.Path)
+
+	return nil
+}
@@ -0,0 +1,338 @@
+package patch
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("..", "testdata", "Patch", "*.txt"))
+	if err != nil || len(files) < 1 {
+		t.Fatal("Couldn't find any files to test")
+	}
+
+	for _, file := range files {
+		input, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r := NewReader(bytes.NewReader(input))
+
+		var out bytes.Buffer
+		w := NewWriter(&out)
+
+		for i := 0; ; i++ {
+			b, err := r.Read()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%s: %v", file, err)
+			}
+
+			// Version and newline style are known once the header was read
+			if i == 0 {
+				w.Format = r.Format()
+
+				if err := w.WriteVersion(r.Version()); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := w.Write(b); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// Trailer is known after the last block
+		w.Format = r.Format()
+
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(input, out.Bytes()) {
+			t.Errorf("%s: output isn't equal to input", file)
+		}
+	}
+}
+
+func TestReaderErrors(t *testing.T) {
+	r := NewReader(strings.NewReader("> BEGIN STRING\nテスト\n> CONTEXT: Map001/1/1/Dialogue/0 < UNTRANSLATED\n\n> END STRING\n"))
+
+	b, err := r.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b.Original != "テスト\n" || len(b.Translations) != 1 || b.Translations[0].Translated {
+		t.Errorf("unexpected block %+v", b)
+	}
+
+	if _, err := r.Read(); err == nil || err == io.EOF {
+		t.Errorf("expected error for missing version, got %v", err)
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "patch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "Map001.txt")
+
+	if err := ioutil.WriteFile(path, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	file := File{Path: path, Version: "RPGMAKER TRANS PATCH FILE VERSION 3.2"}
+
+	if err := WriteFile(file, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != "> RPGMAKER TRANS PATCH FILE VERSION 3.2\n" {
+		t.Errorf("unexpected content %q", data)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("file mode changed to %v", info.Mode())
+	}
+
+	// Temporary file is renamed over original
+	if entries, _ := ioutil.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("expected only patch file in directory, found %d files", len(entries))
+	}
+}
+
+func TestFormatDetection(t *testing.T) {
+	dir := filepath.Join("..", "testdata", "Patch")
+
+	basic, err := ReadFile(filepath.Join(dir, "000 Basic.txt"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		file   string
+		format Format
+	}{
+		{"000 Basic.txt", Format{"\n", false, "\n\n"}},
+		{"001 CRLF.txt", Format{"\r\n", false, "\r\n\r\n"}},
+		{"002 BOM.txt", Format{"\n", true, "\n\n"}},
+		{"003 BOM CRLF.txt", Format{"\r\n", true, "\r\n\r\n"}},
+		{"004 No final newline.txt", Format{"\n", false, ""}},
+		{"005 Extra blank lines.txt", Format{"\n", false, "\n\n\n\n"}},
+	}
+
+	for _, tt := range tests {
+		file, err := ReadFile(filepath.Join(dir, tt.file), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if file.Format != tt.format {
+			t.Errorf("%s: format = %#v, want %#v", tt.file, file.Format, tt.format)
+		}
+
+		// Line endings and BOM don't change the content
+		if !reflect.DeepEqual(file.Blocks, basic.Blocks) {
+			t.Errorf("%s: blocks differ from LF version", tt.file)
+		}
+	}
+}
+
+func TestUnknownDirectives(t *testing.T) {
+	path := filepath.Join("..", "testdata", "Patch", "006 Unknown directives.txt")
+
+	if _, err := ReadFile(path, true); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("strict mode error = %v, want unknown directive on line 2", err)
+	}
+
+	file, err := ReadFile(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(file.Blocks) != 2 {
+		t.Fatalf("expected 2 blocks, got %d", len(file.Blocks))
+	}
+
+	first, second := file.Blocks[0], file.Blocks[1]
+
+	if !reflect.DeepEqual(first.Before, []string{"GENERATED BY: some-tool 1.0"}) {
+		t.Errorf("first block Before = %q", first.Before)
+	}
+
+	if !reflect.DeepEqual(second.Before, []string{"BLOCK ID: 2"}) {
+		t.Errorf("second block Before = %q", second.Before)
+	}
+
+	want := []Directive{{Text: "NOTE: before original", Line: 0}, {Text: "NOTE: after original", Line: 1}}
+	if !reflect.DeepEqual(first.Directives, want) {
+		t.Errorf("first block Directives = %+v, want %+v", first.Directives, want)
+	}
+
+	if d := first.Translations[0].Directives; len(d) != 1 || d[0].Text != "REVIEWED" {
+		t.Errorf("first translation Directives = %+v", d)
+	}
+
+	// Directive stays with translation when it's replaced
+	second.Translations[1].Text = "Yes!\n"
+	second.Translations[1].Touched = true
+
+	var out bytes.Buffer
+
+	w := NewWriter(&out)
+	if err := w.Write(second); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	if !strings.Contains(out.String(), "Yes!\n> NOTE: end of translation\n> END STRING") {
+		t.Errorf("directive was moved or lost:\n%s", out.String())
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	input := `stray text
+> RPGMAKER TRANS PATCH FILE VERSION 3.2
+> BEGIN STRING
+C:\path
+> CONTEXT: Map001/1/1/Dialogue/0 < UNTRANSLATED
+
+> BEGIN STRING
+テスト
+> CONTEXT:
+> END STRING
+> END STRING
+`
+
+	r := NewReader(strings.NewReader(input))
+
+	var blocks []PatchBlock
+
+	for {
+		b, err := r.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+
+		blocks = append(blocks, b)
+	}
+
+	// Unterminated block is ended where the next one starts
+	if len(blocks) != 2 || blocks[0].Original != "C:\\path\n" || blocks[1].Original != "テスト\n" {
+		t.Errorf("unexpected blocks %+v", blocks)
+	}
+
+	want := []Diagnostic{
+		{Line: 1, Severity: Error, Message: "text outside of block is lost when file is written"},
+		{Line: 2, Severity: Warning, Message: "version header isn't on the first line"},
+		{Line: 4, Severity: Warning, Message: `text isn't escaped correctly and is written as "C:\\\\path"`},
+		{Line: 3, Severity: Error, Message: "block has no > END STRING"},
+		{Line: 9, Severity: Warning, Message: `malformed context "> CONTEXT:"`},
+		{Line: 7, Severity: Warning, Message: "block has no contexts"},
+		{Line: 11, Severity: Error, Message: "> END STRING without > BEGIN STRING"},
+	}
+
+	if got := r.Diagnostics(); !reflect.DeepEqual(got, want) {
+		t.Errorf("diagnostics:\n%v\nwant:\n%v", got, want)
+	}
+
+	d := Diagnostic{Path: "Map001.txt", Line: 3, Severity: Error, Message: "block has no > END STRING"}
+	if d.String() != "Map001.txt:3: error: block has no > END STRING" {
+		t.Errorf("unexpected format %q", d.String())
+	}
+}
+
+func TestDiff(t *testing.T) {
+	file, err := ReadFile(filepath.Join("..", "testdata", "Patch", "000 Basic.txt"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+
+	if err := Diff(&out, "Basic.txt", file, file, nil); err != nil || out.Len() > 0 {
+		t.Errorf("unchanged file: %q, %v", out.String(), err)
+	}
+
+	changed := file
+	changed.Blocks = CopyBlocks(file.Blocks)
+	changed.Blocks[2].Translations[0].Text = "Cool guy"
+	changed.Blocks[2].Translations[0].Touched = true
+
+	lb := func(s string) string { return strings.Replace(s, " ", "\n", -1) }
+
+	if err := Diff(&out, "Basic.txt", file, changed, lb); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `--- a/Basic.txt
++++ b/Basic.txt
+@@ -19,5 +19,6 @@ Commonevents/10/26/Dialogue/9 (line breaks added)
+ > BEGIN STRING
+ Kuhl :\>
+ > CONTEXT: Commonevents/10/26/Dialogue/9
+-Cool :)
++Cool
++guy
+ > END STRING
+`
+
+	if out.String() != want {
+		t.Errorf("diff:\n%s\nwant:\n%s", out.String(), want)
+	}
+
+	if file.Blocks[2].Translations[0].Text != "Cool :)\n" {
+		t.Error("original blocks were changed")
+	}
+}
+
+func TestTextBetweenBlocks(t *testing.T) {
+	input := "> RPGMAKER TRANS PATCH FILE VERSION 3.2\n" +
+		"> BEGIN STRING\nA\n> CONTEXT: Map001/1/1/Dialogue/0\n\n> END STRING\n" +
+		"lost\n" +
+		"> BEGIN STRING\nB\n> CONTEXT: Map001/1/1/Dialogue/1\n\n> END STRING\n" +
+		"kept\n"
+
+	r := NewReader(strings.NewReader(input))
+
+	for {
+		if _, err := r.Read(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []Diagnostic{
+		{Line: 7, Severity: Error, Message: "text outside of block is lost when file is written"},
+		{Line: 13, Severity: Warning, Message: "text after the last block"},
+	}
+
+	if got := r.Diagnostics(); !reflect.DeepEqual(got, want) {
+		t.Errorf("diagnostics:\n%v\nwant:\n%v", got, want)
+	}
+}
@@ -0,0 +1,400 @@
+package patch
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"gitgud.io/softashell/rpgmaker-patch-translator/text"
+	"github.com/dimchansky/utfbom"
+	"github.com/pkg/errors"
+)
+
+// Format describes byte level details of patch file that are kept when it's written back
+type Format struct {
+	Newline string // Line ending used in the file, "\n" or "\r\n"
+	BOM     bool   // File starts with UTF-8 byte order mark
+	Trailer string // Raw bytes after the last "> END STRING", including its line ending
+}
+
+// DefaultFormat is used for new files
+var DefaultFormat = Format{
+	Newline: "\n",
+	Trailer: "\n\n",
+}
+
+// Reader reads blocks from patch file one at a time
+type Reader struct {
+	// Strict makes unknown directives an error instead of keeping them in the block
+	Strict bool
+
+	s    *bufio.Scanner
+	err  error
+	line int
+
+	version string
+	format  Format
+
+	diagnostics []Diagnostic
+
+	newlineFound bool
+	afterBlock   bool  // Lines after "> END STRING" are collected in trailer until next block starts
+	gapText      []int // Lines of text seen after the last block
+
+	inBlock     bool
+	blockLine   int // Line of "> BEGIN STRING" of current block
+	original    bool
+	translation bool
+
+	orig         string
+	trans        string
+	contexts     []string
+	translations []TranslationBlock
+
+	before       []string
+	directives   []Directive
+	tlDirectives []Directive
+}
+
+// NewReader returns Reader that reads patch from r, UTF-8 BOM is skipped and remembered in Format
+func NewReader(r io.Reader) *Reader {
+	br, enc := utfbom.Skip(r)
+
+	s := bufio.NewScanner(br)
+	s.Split(scanRawLines)
+
+	return &Reader{
+		s: s,
+		format: Format{
+			Newline: DefaultFormat.Newline,
+			BOM:     enc == utfbom.UTF8,
+		},
+	}
+}
+
+// Version returns version line of the patch without "> " prefix, it's empty until the line is read
+func (r *Reader) Version() string {
+	return r.version
+}
+
+// Format returns format of the patch, newline style is known after the first line
+// and trailer once every block was read
+func (r *Reader) Format() Format {
+	return r.format
+}
+
+// Diagnostics returns problems found in the lines read so far
+func (r *Reader) Diagnostics() []Diagnostic {
+	return r.diagnostics
+}
+
+func (r *Reader) report(line int, severity Severity, format string, args ...interface{}) {
+	r.diagnostics = append(r.diagnostics, Diagnostic{
+		Line:     line,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// scanRawLines is bufio.ScanLines that keeps line endings
+func scanRawLines(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return i + 1, data[:i+1], nil
+	}
+
+	if atEOF {
+		return len(data), data, nil
+	}
+
+	return 0, nil, nil
+}
+
+// strip removes line ending from raw line and detects newline style from the first one
+func (r *Reader) strip(raw string) string {
+	l := strings.TrimSuffix(raw, "\n")
+	if len(l) == len(raw) {
+		return l // Last line without line ending
+	}
+
+	if !r.newlineFound {
+		r.newlineFound = true
+
+		if strings.HasSuffix(l, "\r") {
+			r.format.Newline = "\r\n"
+		}
+	}
+
+	return strings.TrimSuffix(l, "\r")
+}
+
+// Read returns next block, io.EOF is returned after the last one
+func (r *Reader) Read() (PatchBlock, error) {
+	if r.err != nil {
+		return PatchBlock{}, r.err
+	}
+
+	for r.s.Scan() {
+		raw := r.s.Text()
+		l := r.strip(raw)
+
+		r.line++
+
+		if r.afterBlock {
+			if !strings.HasPrefix(l, "> BEGIN STRING") {
+				r.format.Trailer += raw
+
+				if strings.HasPrefix(l, "> END STRING") || strings.HasPrefix(l, "> CONTEXT") {
+					r.outsideBlock(l[2:])
+				} else if strings.HasPrefix(l, "> ") {
+					if err := r.unknown(l[2:]); err != nil {
+						r.err = err
+						return PatchBlock{}, err
+					}
+				} else if len(strings.TrimSpace(l)) > 0 {
+					// Only text after the last block is kept in trailer
+					r.gapText = append(r.gapText, r.line)
+				}
+
+				continue
+			}
+
+			r.afterBlock = false
+			r.format.Trailer = ""
+
+			for _, line := range r.gapText {
+				r.report(line, Error, "text outside of block is lost when file is written")
+			}
+
+			r.gapText = nil
+		}
+
+		if strings.HasPrefix(l, "> ") {
+			l = l[2:]
+
+			switch {
+			case strings.HasPrefix(l, "RPGMAKER TRANS PATCH FILE VERSION") || strings.HasPrefix(l, "WOLF TRANS PATCH FILE VERSION 1.0"):
+				if len(r.version) > 0 {
+					r.report(r.line, Warning, "duplicate version header")
+				} else if r.line > 1 {
+					r.report(r.line, Warning, "version header isn't on the first line")
+				}
+
+				r.version = l
+			case strings.HasPrefix(l, "BEGIN STRING"):
+				if r.inBlock {
+					// Previous block is ended here so the next one isn't merged into it
+					r.report(r.blockLine, Error, "block has no > END STRING")
+
+					b := r.endBlock()
+					r.beginBlock()
+
+					return b, nil
+				}
+
+				r.beginBlock()
+			case !r.inBlock && (strings.HasPrefix(l, "CONTEXT") || strings.HasPrefix(l, "END STRING")):
+				r.outsideBlock(l)
+			case strings.HasPrefix(l, "CONTEXT"):
+				r.readContext(l)
+			case strings.HasPrefix(l, "END STRING"):
+				r.afterBlock = true
+				r.format.Trailer = raw[len(l)+2:]
+
+				return r.endBlock(), nil
+			default:
+				if err := r.unknown(l); err != nil {
+					r.err = err
+					return PatchBlock{}, err
+				}
+			}
+
+			continue
+		}
+
+		if !r.inBlock && len(strings.TrimSpace(l)) > 0 {
+			r.report(r.line, Error, "text outside of block is lost when file is written")
+		}
+
+		if r.original || r.translation {
+			r.checkEscapes(l)
+		}
+
+		if !strings.HasSuffix(l, "\n") && (r.original || r.translation) {
+			l += "\n"
+		}
+
+		if r.original {
+			r.orig += l
+		} else if r.translation {
+			r.trans += l
+		}
+	}
+
+	if r.inBlock {
+		r.inBlock = false
+		r.report(r.blockLine, Error, "block has no > END STRING")
+	}
+
+	for _, line := range r.gapText {
+		r.report(line, Warning, "text after the last block")
+	}
+
+	r.gapText = nil
+
+	if err := r.s.Err(); err != nil {
+		r.err = errors.Wrap(err, "error while scanning patch file")
+	} else if len(r.version) < 3 {
+		r.err = fmt.Errorf("No patch version found")
+	} else {
+		r.err = io.EOF
+	}
+
+	return PatchBlock{}, r.err
+}
+
+// unknown keeps directive that isn't understood at its position so it can be written back
+func (r *Reader) unknown(l string) error {
+	if r.Strict {
+		return fmt.Errorf("line %d: unknown directive %q", r.line, "> "+l)
+	}
+
+	r.report(r.line, Warning, "unknown directive %q is kept as it is", "> "+l)
+
+	switch {
+	case r.original:
+		r.directives = append(r.directives, Directive{Text: l, Line: strings.Count(r.orig, "\n")})
+	case r.inBlock:
+		r.tlDirectives = append(r.tlDirectives, Directive{Text: l, Line: strings.Count(r.trans, "\n")})
+	default:
+		r.before = append(r.before, l)
+	}
+
+	return nil
+}
+
+// outsideBlock reports directive that only makes sense inside a block, it's skipped
+func (r *Reader) outsideBlock(l string) {
+	if strings.HasPrefix(l, "CONTEXT") {
+		r.report(r.line, Error, "context outside of block")
+	} else {
+		r.report(r.line, Error, "> END STRING without > BEGIN STRING")
+	}
+}
+
+// checkEscapes reports text line that would change after being unescaped and escaped again
+func (r *Reader) checkEscapes(l string) {
+	if escaped := text.Escape(text.Unescape(l)); escaped != l {
+		r.report(r.line, Warning, "text isn't escaped correctly and is written as %q", escaped)
+	}
+}
+
+func (r *Reader) beginBlock() {
+	r.inBlock = true
+	r.blockLine = r.line
+	r.original = true
+	r.translation = false
+}
+
+func (r *Reader) readContext(l string) {
+	if r.translation && len(r.trans) > 0 {
+		var translated bool
+
+		if len(strings.TrimRight(r.trans, "\n")) < 1 {
+			r.trans = ""
+			translated = false
+		} else {
+			translated = true
+		}
+
+		r.translations = append(r.translations, TranslationBlock{
+			Text:       text.Unescape(r.trans),
+			Contexts:   r.contexts,
+			Translated: translated,
+			Directives: r.tlDirectives,
+		})
+
+		r.trans = ""
+		r.contexts = nil
+		r.tlDirectives = nil
+	} else {
+		r.original = false
+		r.translation = true
+	}
+
+	if !strings.HasPrefix(l, "CONTEXT: ") || len(strings.TrimSpace(l[len("CONTEXT: "):])) < 1 {
+		r.report(r.line, Warning, "malformed context %q", "> "+l)
+	}
+
+	if len(l) > len("CONTEXT")+1 {
+		start := len("CONTEXT")
+		end := strings.Index(l, " < UNTRANSLATED")
+		if end == -1 {
+			r.contexts = append(r.contexts, l[start:])
+		} else {
+			r.contexts = append(r.contexts, l[start:end])
+		}
+	}
+}
+
+func (r *Reader) endBlock() PatchBlock {
+	r.inBlock = false
+	r.original = false
+	r.translation = false
+
+	if len(r.trans) > 0 {
+		var translated bool
+
+		if len(strings.TrimRight(r.trans, "\n")) < 1 {
+			r.trans = ""
+			translated = false
+		} else {
+			translated = true
+		}
+
+		r.translations = append(r.translations, TranslationBlock{
+			Text:       text.Unescape(r.trans),
+			Contexts:   r.contexts,
+			Translated: translated,
+			Directives: r.tlDirectives,
+		})
+	} else if len(r.contexts) > 0 {
+		r.translations = append(r.translations, TranslationBlock{
+			Text:       text.Unescape(r.trans),
+			Contexts:   r.contexts,
+			Translated: false,
+			Directives: r.tlDirectives,
+		})
+	} else {
+		if len(r.translations) == 0 {
+			r.report(r.blockLine, Warning, "block has no contexts")
+		}
+
+		// Nothing to attach them to, they end up after original text
+		r.directives = append(r.directives, r.tlDirectives...)
+	}
+
+	b := PatchBlock{
+		Original:     text.Unescape(r.orig),
+		Translations: r.translations,
+		Before:       r.before,
+		Directives:   r.directives,
+	}
+
+	r.orig = ""
+	r.trans = ""
+
+	r.contexts = nil
+	r.translations = nil
+
+	r.before = nil
+	r.directives = nil
+	r.tlDirectives = nil
+
+	return b
+}
@@ -0,0 +1,168 @@
+package patch
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"gitgud.io/softashell/rpgmaker-patch-translator/text"
+)
+
+const bom = "\xef\xbb\xbf"
+
+// LineBreaker splits escaped translation into lines that fit in game window
+type LineBreaker func(text string) string
+
+// Writer writes patch blocks in the same format Reader reads them
+type Writer struct {
+	w *bufio.Writer
+
+	// Format is DefaultFormat unless changed, BOM and newline have to be set before
+	// the first write and trailer before Close
+	Format Format
+
+	started bool // BOM was written
+	pending bool // Last block still needs its trailing line endings
+
+	// LineBreaker is applied to new translations in contexts that need line breaks, nil leaves them as they are
+	LineBreaker LineBreaker
+}
+
+// NewWriter returns buffered Writer, Close has to be called after the last block
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{
+		w:      bufio.NewWriter(w),
+		Format: DefaultFormat,
+	}
+}
+
+// write converts line endings to the ones used by the file
+func (w *Writer) write(s string) error {
+	if !w.started {
+		w.started = true
+
+		if w.Format.BOM {
+			if _, err := w.w.WriteString(bom); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(w.Format.Newline) > 0 && w.Format.Newline != "\n" {
+		s = strings.Replace(s, "\n", w.Format.Newline, -1)
+	}
+
+	_, err := w.w.WriteString(s)
+
+	return err
+}
+
+// WriteVersion writes version line, it should come before any blocks
+func (w *Writer) WriteVersion(version string) error {
+	return w.write(fmt.Sprintf("> %s\n", version))
+}
+
+// Write writes a single block
+func (w *Writer) Write(b PatchBlock) error {
+	var start string
+	if w.pending {
+		start = "\n\n"
+	}
+
+	for _, d := range b.Before {
+		start += "> " + d + "\n"
+	}
+
+	if err := w.write(start + "> BEGIN STRING\n"); err != nil {
+		return err
+	}
+
+	if err := w.write(withDirectives(text.Escape(b.Original), b.Directives)); err != nil {
+		return err
+	}
+
+	for _, t := range b.Translations {
+		for _, context := range t.Contexts {
+			context = fmt.Sprintf("> CONTEXT%s", context)
+
+			if !t.Translated {
+				context += " < UNTRANSLATED\n"
+			} else {
+				context += "\n"
+			}
+
+			if err := w.write(context); err != nil {
+				return err
+			}
+		}
+
+		var trans string
+
+		if t.Translated {
+			trans = text.Escape(t.Text)
+
+			if t.Touched && w.LineBreaker != nil && ShouldBreakLines(t.Contexts) {
+				trans = w.LineBreaker(trans)
+			}
+
+			if !strings.HasSuffix(trans, "\n") {
+				trans += "\n"
+			}
+		} else {
+			trans = "\n"
+		}
+
+		if err := w.write(withDirectives(trans, t.Directives)); err != nil {
+			return err
+		}
+	}
+
+	w.pending = true
+
+	return w.write("> END STRING")
+}
+
+// withDirectives puts directives back between lines of text, ones past the last line go at the end
+func withDirectives(s string, directives []Directive) string {
+	if len(directives) < 1 {
+		return s
+	}
+
+	var out strings.Builder
+
+	d := 0
+
+	for i, line := range strings.SplitAfter(s, "\n") {
+		for ; d < len(directives) && directives[d].Line <= i; d++ {
+			out.WriteString("> " + directives[d].Text + "\n")
+		}
+
+		out.WriteString(line)
+	}
+
+	for ; d < len(directives); d++ {
+		out.WriteString("> " + directives[d].Text + "\n")
+	}
+
+	return out.String()
+}
+
+// Flush writes buffered data to underlying io.Writer
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
+
+// Close ends the last block with format trailer and flushes, it doesn't close underlying io.Writer
+func (w *Writer) Close() error {
+	if w.pending {
+		w.pending = false
+
+		// Trailer is kept as it was read
+		if _, err := w.w.WriteString(w.Format.Trailer); err != nil {
+			return err
+		}
+	}
+
+	return w.Flush()
+}
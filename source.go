@@ -0,0 +1,60 @@
+package main
+
+import (
+	"path/filepath"
+
+	"gitgud.io/softashell/rpgmaker-patch-translator/engine"
+	"gitgud.io/softashell/rpgmaker-patch-translator/mv"
+	"gitgud.io/softashell/rpgmaker-patch-translator/patch"
+	log "github.com/sirupsen/logrus"
+)
+
+// sourceDir returns directory with files that get translated relative to dir,
+// it's Patch unless game is MV or MZ and its data files are translated directly
+func sourceDir(dir string) string {
+	if engine.Is(engine.MV) {
+		return mv.DataDir(dir)
+	}
+
+	return "Patch"
+}
+
+// sourceFiles lists every file that gets translated
+func sourceFiles(dir string) []string {
+	if !engine.Is(engine.MV) {
+		return getDirectoryContents(filepath.Join(dir, "Patch"))
+	}
+
+	matches, err := filepath.Glob(filepath.Join(dir, sourceDir(dir), "*.json"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var files []string
+
+	for _, m := range matches {
+		if mv.IsDataFile(m) {
+			files = append(files, m)
+		}
+	}
+
+	return files
+}
+
+// readSource reads blocks of patch file or MV data file
+func readSource(file string) (patch.File, error) {
+	if engine.Is(engine.MV) {
+		return mv.ReadFile(file)
+	}
+
+	return patch.ReadFile(file, strictPatch)
+}
+
+// writeSource writes translated blocks of file read from src to pf.Path
+func writeSource(src string, pf patch.File) error {
+	if engine.Is(engine.MV) {
+		return mv.WriteFile(src, pf, breakLines)
+	}
+
+	return patch.WriteFile(pf, breakLines)
+}
@@ -1,5 +1,11 @@
 package statictl
 
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
 type TranslationType int
 
 const (
@@ -28,6 +34,16 @@ var databaseFiles = map[TranslationType]string{
 	TransSystem:       "System.hjson",
 }
 
+// String returns name of the type, same as its database file without extension
+func (t TranslationType) String() string {
+	name, ok := databaseFiles[t]
+	if !ok {
+		return fmt.Sprintf("TranslationType(%d)", int(t))
+	}
+
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
 type DatabaseType int
 
 const (
@@ -0,0 +1,194 @@
+package statictl
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Glossary maps terms in original text to translations that should always be used for them
+type Glossary map[string]string
+
+// LoadGlossary reads glossary in the same format as simple translation databases,
+// missing file results in empty glossary
+func LoadGlossary(fileName string) (Glossary, error) {
+	g := make(Glossary)
+
+	if !fileExists(fileName) {
+		return g, nil
+	}
+
+	t := &Db{}
+
+	db, err := t.loadDatabaseStatic(fileName)
+	if err != nil {
+		return g, err
+	}
+
+	for original, translated := range db {
+		if len(original) < 1 || len(translated) < 1 {
+			continue
+		}
+
+		g[original] = translated
+	}
+
+	return g, nil
+}
+
+// Hits counts glossary terms found in original text that use expected translation,
+// placeholders that survived translation are counted as well
+func (g Glossary) Hits(original, translation string) int {
+	hits := len(placeholderRegex.FindAllString(translation, -1))
+
+	translation = strings.ToLower(translation)
+
+	for term, tl := range g {
+		if strings.Contains(original, term) && strings.Contains(translation, strings.ToLower(tl)) {
+			hits++
+		}
+	}
+
+	return hits
+}
+
+var placeholderRegex = regexp.MustCompile(`\{\s*[Tt]\s*(\d+)\s*\}`)
+
+func placeholder(i int) string {
+	return fmt.Sprintf("{T%d}", i)
+}
+
+// PlaceholderIndexes returns indexes of placeholders found in str, tolerating changes
+// to spacing and case made by translation service
+func PlaceholderIndexes(str string) []int {
+	var indexes []int
+
+	for _, m := range placeholderRegex.FindAllStringSubmatch(str, -1) {
+		if i, err := strconv.Atoi(m[1]); err == nil {
+			indexes = append(indexes, i)
+		}
+	}
+
+	return indexes
+}
+
+// terms returns glossary terms with longer ones first so they aren't broken up by shorter ones,
+// position of term in the list is its placeholder index
+func (g Glossary) terms() []string {
+	terms := make([]string, 0, len(g))
+	for term := range g {
+		terms = append(terms, term)
+	}
+
+	sort.Slice(terms, func(i, j int) bool {
+		if len(terms[i]) != len(terms[j]) {
+			return len(terms[i]) > len(terms[j])
+		}
+
+		return terms[i] < terms[j]
+	})
+
+	return terms
+}
+
+// SetGlossary makes ProtectTerms replace glossary terms with placeholders that
+// are turned into translations of the terms by post translation
+func (t *Db) SetGlossary(g Glossary) {
+	t.glossary = g
+	t.terms = g.terms()
+}
+
+// ProtectTerms replaces glossary terms with placeholders before text is sent to translation service,
+// terms that are part of a longer word are left alone. Static translations use the text without placeholders
+func (t *Db) ProtectTerms(str string) string {
+	for i, term := range t.terms {
+		var out strings.Builder
+
+		rest := str
+
+		for {
+			pos := strings.Index(rest, term)
+			if pos < 0 {
+				break
+			}
+
+			end := pos + len(term)
+
+			out.WriteString(rest[:pos])
+
+			if insideWord(rest[:pos], term, rest[end:]) {
+				out.WriteString(term)
+			} else {
+				out.WriteString(placeholder(i))
+			}
+
+			rest = rest[end:]
+		}
+
+		out.WriteString(rest)
+
+		str = out.String()
+	}
+
+	return str
+}
+
+// restoreTerms replaces placeholders with translations of glossary terms, unknown ones are kept
+func (t *Db) restoreTerms(str string) (string, error) {
+	var unknown []string
+
+	str = placeholderRegex.ReplaceAllStringFunc(str, func(m string) string {
+		i, err := strconv.Atoi(placeholderRegex.FindStringSubmatch(m)[1])
+		if err != nil || i >= len(t.terms) {
+			unknown = append(unknown, m)
+			return m
+		}
+
+		return t.glossary[t.terms[i]]
+	})
+
+	if len(unknown) > 0 {
+		return str, fmt.Errorf("unknown glossary placeholders %q", unknown)
+	}
+
+	return str, nil
+}
+
+// insideWord reports if term found between before and after continues a longer word,
+// like katakana term in a longer katakana word, kanji one in a compound or latin one in a longer latin word
+func insideWord(before, term, after string) bool {
+	first, _ := utf8.DecodeRuneInString(term)
+	last, _ := utf8.DecodeLastRuneInString(term)
+
+	if r, n := utf8.DecodeLastRuneInString(before); n > 0 && sameWord(r, first) {
+		return true
+	}
+
+	if r, n := utf8.DecodeRuneInString(after); n > 0 && sameWord(last, r) {
+		return true
+	}
+
+	return false
+}
+
+// sameWord reports if a and b belong to the same word. Adjacent kanji form compounds (王 in 王国),
+// while hiragana and hangul are particles and suffixes that never continue a term
+func sameWord(a, b rune) bool {
+	isKatakana := func(r rune) bool {
+		return unicode.Is(unicode.Katakana, r) || r == 'ー' || r == 'ｰ'
+	}
+
+	isHan := func(r rune) bool {
+		return unicode.Is(unicode.Han, r)
+	}
+
+	isLetter := func(r rune) bool {
+		return (unicode.IsLetter(r) || unicode.IsDigit(r)) && !unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+	}
+
+	return (isKatakana(a) && isKatakana(b)) || (isHan(a) && isHan(b)) || (isLetter(a) && isLetter(b))
+}
@@ -0,0 +1,113 @@
+package statictl
+
+import (
+	"testing"
+)
+
+func TestGlossaryTranslation(t *testing.T) {
+	db := &Db{}
+	db.SetGlossary(Glossary{
+		"勇者":    "Hero",
+		"勇者の剣":  "Sword of the Hero",
+		"ポーション": "Potion",
+		"アル":    "Al",
+		"Tom":   "Tomu",
+		"王":     "King",
+	})
+
+	tests := []struct {
+		name        string
+		input       string
+		protected   string
+		translation string
+		want        string
+	}{
+		{
+			"single term",
+			"勇者が来た",
+			"{T3}が来た",
+			"{T3} came",
+			"Hero came",
+		},
+		{
+			"longest term first",
+			"勇者の剣とポーションを持つ勇者",
+			"{T1}と{T0}を持つ{T3}",
+			"{T3} with {t1} and { T0 }",
+			"Hero with Sword of the Hero and Potion",
+		},
+		{
+			"no terms",
+			"こんにちは",
+			"こんにちは",
+			"Hello",
+			"Hello",
+		},
+		{
+			"term inside longer katakana word",
+			"アルバイトのアルです",
+			"アルバイトの{T2}です",
+			"I'm {T2} from part-time job",
+			"I'm Al from part-time job",
+		},
+		{
+			"term inside longer latin word",
+			"Tomato、Tomさん",
+			"Tomato、{T4}さん",
+			"Tomato, Mr. {T4}",
+			"Tomato, Mr. Tomu",
+		},
+		{
+			"term inside kanji compound",
+			"王国の女王と王が来た",
+			"王国の女王と{T5}が来た",
+			"The queen of the kingdom and {T5} came",
+			"The queen of the kingdom and King came",
+		},
+		{
+			"unknown placeholder",
+			"勇者が来た",
+			"{T3}が来た",
+			"{T3} came {T9}",
+			"Hero came {T9}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if protected := db.ProtectTerms(tt.input); protected != tt.protected {
+				t.Errorf("ProtectTerms() = %q, want %q", protected, tt.protected)
+			}
+
+			got, err := db.RunPostTranslation(tt.translation)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tt.want {
+				t.Errorf("RunPostTranslation() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGlossaryStaticTranslation(t *testing.T) {
+	db := &Db{
+		db: translationDBMap{TransGeneric: {"勇者が来た": "The hero has arrived"}},
+	}
+	db.SetGlossary(Glossary{"勇者": "Hero"})
+
+	str, err := db.RunPreTranslation("勇者が来た")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := db.GetTranslation(str, TransDialogue)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got != "The hero has arrived" {
+		t.Errorf("GetTranslation(%q) = %q, want %q", str, got, "The hero has arrived")
+	}
+}
@@ -23,6 +23,9 @@ type Db struct {
 
 	dbPost   translationDBMap
 	dbRePost translationDBRegexMap
+
+	glossary Glossary
+	terms    []string // Glossary terms by placeholder index
 }
 
 func New() *Db {
@@ -10,7 +10,10 @@ import (
 func (t *Db) RunPostTranslation(str string) (string, error) {
 	str = strings.TrimSpace(str) // Might not be a good idea
 
-	var err error
+	str, err := t.restoreTerms(str)
+	if err != nil {
+		log.Warn(err)
+	}
 
 	str, err = t.applyPostStatic(str, TransGeneric)
 	if err != nil {
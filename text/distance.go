@@ -0,0 +1,50 @@
+package text
+
+// Levenshtein returns amount of single rune edits needed to turn a into b
+func Levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	if len(ra) < len(rb) {
+		ra, rb = rb, ra
+	}
+
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev, cur = cur, prev
+	}
+
+	return prev[len(rb)]
+}
+
+// Similarity returns 1 for equal strings and 0 for completely different ones
+func Similarity(a, b string) float64 {
+	la, lb := len([]rune(a)), len([]rune(b))
+
+	longest := la
+	if lb > longest {
+		longest = lb
+	}
+
+	if longest == 0 {
+		return 1
+	}
+
+	return 1 - float64(Levenshtein(a, b))/float64(longest)
+}
@@ -1,6 +1,7 @@
 package text
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
 	"unicode"
@@ -24,8 +25,57 @@ var (
 		".txt",
 		".csv",
 	}
+
+	// Scripts that are only found in text written in that language
+	languageScripts = map[string][]*unicode.RangeTable{
+		"ja": {unicode.Hiragana, unicode.Katakana, unicode.Han},
+		"zh": {unicode.Han, unicode.Bopomofo},
+		"ko": {unicode.Hangul, unicode.Han},
+		"ru": {unicode.Cyrillic},
+	}
+
+	sourceScripts = languageScripts["ja"]
 )
 
+// SetSourceLanguage changes which text is considered translatable
+func SetSourceLanguage(lang string) error {
+	scripts, ok := languageScripts[lang]
+	if !ok {
+		return fmt.Errorf("unsupported source language %q", lang)
+	}
+
+	sourceScripts = scripts
+
+	return nil
+}
+
+// HasUntranslated returns true if translated text still contains characters of source language.
+// Scripts shared by source and target language, like Han for Japanese and Chinese, are ignored
+func HasUntranslated(text, target string) bool {
+	if !ShouldTranslate(text) {
+		return false
+	}
+
+	var scripts []*unicode.RangeTable
+
+	for _, s := range sourceScripts {
+		var shared bool
+
+		for _, t := range languageScripts[target] {
+			if s == t {
+				shared = true
+				break
+			}
+		}
+
+		if !shared {
+			scripts = append(scripts, s)
+		}
+	}
+
+	return containsScript(width.Narrow.String(text), scripts)
+}
+
 func ShouldTranslate(text string) bool {
 	text = strings.TrimSpace(text)
 
@@ -47,14 +97,12 @@ func ShouldTranslate(text string) bool {
 		}
 	}
 
-	return isJapanese(text)
+	return containsScript(text, sourceScripts)
 }
 
-func isJapanese(text string) bool {
+func containsScript(text string, scripts []*unicode.RangeTable) bool {
 	for _, r := range text {
-		if unicode.Is(unicode.Hiragana, r) ||
-			unicode.Is(unicode.Katakana, r) ||
-			unicode.Is(unicode.Han, r) {
+		if unicode.In(r, scripts...) {
 			return true
 		}
 	}
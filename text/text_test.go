@@ -47,6 +47,39 @@ func TestShouldTranslateText(t *testing.T) {
 	}
 }
 
+func TestShouldTranslateLanguage(t *testing.T) {
+	defer SetSourceLanguage("ja")
+
+	var tests = []struct {
+		lang   string
+		input  string
+		output bool
+	}{
+		{"ja", "あの――", true},
+		{"ja", "안녕하세요", false},
+		{"zh", "你好", true},
+		{"zh", "こんにちは", false},
+		{"ko", "안녕하세요", true},
+		{"ru", "Привет", true},
+		{"ru", "test", false},
+	}
+
+	for _, pair := range tests {
+		if err := SetSourceLanguage(pair.lang); err != nil {
+			t.Fatal(err)
+		}
+
+		r := ShouldTranslate(pair.input)
+		if r != pair.output {
+			t.Errorf("For %s input:\n%q\nexpected: %v got: %v\n", pair.lang, pair.input, pair.output, r)
+		}
+	}
+
+	if err := SetSourceLanguage("xx"); err == nil {
+		t.Error("expected error for unsupported language")
+	}
+}
+
 func TestPatchUnescape(t *testing.T) {
 	var tests = []struct {
 		input  string
@@ -236,3 +269,27 @@ func TestExtractLeadingWhitespace(t *testing.T) {
 		})
 	}
 }
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		distance int
+		want     float64
+	}{
+		{"", "", 0, 1},
+		{"abc", "", 3, 0},
+		{"kitten", "sitting", 3, 1 - 3.0/7},
+		{"勇者が来た", "勇者が来た！", 1, 1 - 1.0/6},
+		{"こんにちは", "こんにちは", 0, 1},
+	}
+
+	for _, tt := range tests {
+		if d := Levenshtein(tt.a, tt.b); d != tt.distance {
+			t.Errorf("Levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, d, tt.distance)
+		}
+
+		if s := Similarity(tt.a, tt.b); s != tt.want {
+			t.Errorf("Similarity(%q, %q) = %v, want %v", tt.a, tt.b, s, tt.want)
+		}
+	}
+}
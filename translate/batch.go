@@ -0,0 +1,137 @@
+package translate
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// BatchTranslator is implemented by backends that can translate many texts in one request
+type BatchTranslator interface {
+	Translator
+
+	// TranslateBatch returns responses in the same order as requests
+	TranslateBatch(ctx context.Context, reqs []Request) ([]Response, error)
+}
+
+type batchItem struct {
+	ctx     context.Context
+	request Request
+	result  chan workerResult
+}
+
+// batcher collects requests from concurrent callers and sends them to
+// workers in batches, each caller still receives only its own response
+type batcher struct {
+	size    int
+	delay   time.Duration
+	process func(ctx context.Context, payload interface{}) interface{}
+
+	queue chan batchItem
+}
+
+func newBatcher(size int, delay time.Duration, process func(ctx context.Context, payload interface{}) interface{}) *batcher {
+	b := &batcher{
+		size:    size,
+		delay:   delay,
+		process: process,
+		queue:   make(chan batchItem, size),
+	}
+
+	go b.run()
+
+	return b
+}
+
+// Process queues request and waits until batch containing it is translated,
+// batch is only cancelled once ctx of every request in it is done
+func (b *batcher) Process(ctx context.Context, req Request) workerResult {
+	item := batchItem{
+		ctx:     ctx,
+		request: req,
+		result:  make(chan workerResult, 1),
+	}
+
+	select {
+	case b.queue <- item:
+	case <-ctx.Done():
+		return workerResult{err: ctx.Err()}
+	}
+
+	select {
+	case result := <-item.result:
+		return result
+	case <-ctx.Done():
+		return workerResult{err: ctx.Err()}
+	}
+}
+
+func (b *batcher) run() {
+	for item := range b.queue {
+		batch := []batchItem{item}
+
+		// Wait a bit for other blocks to add their requests
+		timer := time.NewTimer(b.delay)
+
+	collect:
+		for len(batch) < b.size {
+			select {
+			case item, ok := <-b.queue:
+				if !ok {
+					break collect
+				}
+
+				batch = append(batch, item)
+			case <-timer.C:
+				break collect
+			}
+		}
+
+		timer.Stop()
+
+		go b.send(batch)
+	}
+}
+
+func (b *batcher) send(batch []batchItem) {
+	reqs := make([]Request, len(batch))
+	for i, item := range batch {
+		reqs[i] = item.request
+	}
+
+	// Batch is shared by many callers so it's kept going while any of them waits,
+	// worker timeout still applies to every attempt
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		for _, item := range batch {
+			select {
+			case <-item.ctx.Done():
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		cancel()
+	}()
+
+	result := b.process(ctx, reqs).(workerResult)
+	if result.err == nil && len(result.responses) != len(batch) {
+		result.err = fmt.Errorf("translation service returned %d translations for %d texts", len(result.responses), len(batch))
+	}
+
+	for i, item := range batch {
+		if result.err != nil {
+			item.result <- workerResult{err: result.err}
+			continue
+		}
+
+		item.result <- workerResult{response: result.responses[i]}
+	}
+}
+
+// Close stops collecting requests, it must not be called while requests are being processed
+func (b *batcher) Close() {
+	close(b.queue)
+}
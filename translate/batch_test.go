@@ -0,0 +1,142 @@
+package translate
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBatcher(t *testing.T) {
+	var mutex sync.Mutex
+	var batches [][]Request
+
+	process := func(ctx context.Context, payload interface{}) interface{} {
+		reqs := payload.([]Request)
+
+		mutex.Lock()
+		batches = append(batches, reqs)
+		mutex.Unlock()
+
+		var responses []Response
+		for _, req := range reqs {
+			responses = append(responses, Response{TranslationText: "tl:" + req.Text})
+		}
+
+		return workerResult{responses: responses}
+	}
+
+	b := newBatcher(4, 50*time.Millisecond, process)
+	defer b.Close()
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			str := fmt.Sprintf("text %d", i)
+
+			result := b.Process(context.Background(), Request{Text: str})
+			if result.err != nil {
+				t.Error(result.err)
+			}
+
+			if result.response.TranslationText != "tl:"+str {
+				t.Errorf("request %q got response %q", str, result.response.TranslationText)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	total := 0
+	for _, batch := range batches {
+		if len(batch) > 4 {
+			t.Errorf("batch has %d requests, limit is 4", len(batch))
+		}
+
+		total += len(batch)
+	}
+
+	if total != 10 || len(batches) < 3 {
+		t.Errorf("sent %d requests in %d batches", total, len(batches))
+	}
+}
+
+func TestBatcherError(t *testing.T) {
+	process := func(ctx context.Context, payload interface{}) interface{} {
+		return workerResult{}
+	}
+
+	b := newBatcher(2, 10*time.Millisecond, process)
+	defer b.Close()
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if result := b.Process(context.Background(), Request{Text: "text"}); result.err == nil {
+				t.Error("expected mismatched batch to fail")
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestBatcherCancel(t *testing.T) {
+	cancelled := make(chan struct{})
+
+	process := func(ctx context.Context, payload interface{}) interface{} {
+		<-ctx.Done()
+		close(cancelled)
+
+		return workerResult{err: ctx.Err()}
+	}
+
+	b := newBatcher(2, 10*time.Millisecond, process)
+	defer b.Close()
+
+	first, cancelFirst := context.WithCancel(context.Background())
+	second, cancelSecond := context.WithCancel(context.Background())
+
+	var wg sync.WaitGroup
+
+	for _, ctx := range []context.Context{first, second} {
+		wg.Add(1)
+
+		go func(ctx context.Context) {
+			defer wg.Done()
+			b.Process(ctx, Request{Text: "text"})
+		}(ctx)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+
+	// Batch keeps going while the second request waits for it
+	cancelFirst()
+
+	select {
+	case <-cancelled:
+		t.Fatal("batch was cancelled while a request still waited for it")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	cancelSecond()
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("batch wasn't cancelled after every request was")
+	}
+
+	wg.Wait()
+}
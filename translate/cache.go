@@ -0,0 +1,289 @@
+package translate
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/text/width"
+)
+
+// CacheEntry is a single translation stored in cache file
+type CacheEntry struct {
+	Backend     string    `json:"backend"`
+	From        string    `json:"from"`
+	To          string    `json:"to"`
+	Text        string    `json:"text"`
+	Context     string    `json:"context,omitempty"` // Hash of preceding lines sent to backends that support them
+	Translation string    `json:"translation"`
+	Created     time.Time `json:"created"`
+}
+
+// CacheStats describes cache usage since it was opened
+type CacheStats struct {
+	Entries int
+	Hits    int64
+	Misses  int64
+	Writes  int64
+}
+
+// Cache keeps translations in an append only file, one json entry per line.
+// Later entries for the same key replace earlier ones. Safe for concurrent use.
+type Cache struct {
+	path string
+
+	mutex   sync.RWMutex
+	file    *os.File
+	entries map[string]CacheEntry
+
+	hits   int64
+	misses int64
+	writes int64
+}
+
+// OpenCache loads existing cache file or creates a new one
+func OpenCache(path string) (*Cache, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, errors.Wrap(err, "failed to create cache directory")
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open cache file %q", path)
+	}
+
+	c, err := loadCache(path, f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	c.file = f
+
+	return c, nil
+}
+
+// OpenCacheReadOnly loads existing cache file without ever changing it, new translations
+// are only kept in memory. Missing file results in empty cache
+func OpenCacheReadOnly(path string) (*Cache, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return &Cache{path: path, entries: make(map[string]CacheEntry)}, nil
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "failed to open cache file %q", path)
+	}
+	defer f.Close()
+
+	return loadCache(path, f)
+}
+
+// loadCache reads every entry from cache file
+func loadCache(path string, f *os.File) (*Cache, error) {
+	c := &Cache{
+		path:    path,
+		entries: make(map[string]CacheEntry),
+	}
+
+	s := bufio.NewScanner(f)
+	s.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	line := 0
+	for s.Scan() {
+		line++
+
+		var e CacheEntry
+		if err := json.Unmarshal(s.Bytes(), &e); err != nil {
+			// Most likely the last line of interrupted write
+			log.Warnf("Skipping broken entry in cache file %s:%d", path, line)
+			continue
+		}
+
+		c.entries[cacheKey(e.Backend, e.From, e.To, e.Context, e.Text)] = e
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to read cache file %q", path)
+	}
+
+	return c, nil
+}
+
+// NormalizeCacheText returns text the way it's used in cache keys
+func NormalizeCacheText(str string) string {
+	return width.Fold.String(strings.TrimSpace(str))
+}
+
+// ContextHash returns short hash of preceding lines so translations made with different
+// context are cached separately, it's empty without context
+func ContextHash(lines []string) string {
+	if len(lines) < 1 {
+		return ""
+	}
+
+	sum := sha1.Sum([]byte(strings.Join(lines, "\n")))
+
+	return hex.EncodeToString(sum[:8])
+}
+
+func cacheKey(backend, from, to, context, str string) string {
+	return strings.Join([]string{backend, from, to, context, NormalizeCacheText(str)}, "\x00")
+}
+
+// Get returns cached translation of str made with context returned by ContextHash
+func (c *Cache) Get(backend, from, to, context, str string) (string, bool) {
+	c.mutex.RLock()
+	e, ok := c.entries[cacheKey(backend, from, to, context, str)]
+	c.mutex.RUnlock()
+
+	if ok {
+		atomic.AddInt64(&c.hits, 1)
+	} else {
+		atomic.AddInt64(&c.misses, 1)
+	}
+
+	return e.Translation, ok
+}
+
+// Put stores translation of str and appends it to cache file
+func (c *Cache) Put(backend, from, to, context, str, translation string) error {
+	e := CacheEntry{
+		Backend:     backend,
+		From:        from,
+		To:          to,
+		Text:        NormalizeCacheText(str),
+		Context:     context,
+		Translation: translation,
+		Created:     time.Now().UTC(),
+	}
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.entries[cacheKey(backend, from, to, context, str)] = e
+
+	// Read only cache
+	if c.file == nil {
+		return nil
+	}
+
+	if _, err := c.file.Write(append(b, '\n')); err != nil {
+		return errors.Wrap(err, "failed to write cache entry")
+	}
+
+	atomic.AddInt64(&c.writes, 1)
+
+	return nil
+}
+
+// Stats returns usage counters
+func (c *Cache) Stats() CacheStats {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return CacheStats{
+		Entries: len(c.entries),
+		Hits:    atomic.LoadInt64(&c.hits),
+		Misses:  atomic.LoadInt64(&c.misses),
+		Writes:  atomic.LoadInt64(&c.writes),
+	}
+}
+
+// Entries returns every entry matching filter, nil filter matches everything
+func (c *Cache) Entries(filter func(CacheEntry) bool) []CacheEntry {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	var list []CacheEntry
+	for _, e := range c.entries {
+		if filter == nil || filter(e) {
+			list = append(list, e)
+		}
+	}
+
+	return list
+}
+
+// Prune removes entries matching filter and rewrites cache file without
+// them or any replaced entries, returns amount of removed entries
+func (c *Cache) Prune(filter func(CacheEntry) bool) (int, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.file == nil {
+		return 0, errors.Errorf("cache file %q is opened read only", c.path)
+	}
+
+	removed := 0
+	for k, e := range c.entries {
+		if filter != nil && filter(e) {
+			delete(c.entries, k)
+			removed++
+		}
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(c.path), filepath.Base(c.path)+".tmp")
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to create temporary cache file")
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+
+	for _, e := range c.entries {
+		if err := enc.Encode(e); err != nil {
+			tmp.Close()
+			return 0, err
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return 0, errors.Wrap(err, "failed to write cache file")
+	}
+
+	if err := tmp.Close(); err != nil {
+		return 0, err
+	}
+
+	c.file.Close()
+
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return 0, errors.Wrap(err, "failed to replace cache file")
+	}
+
+	c.file, err = os.OpenFile(c.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to open cache file %q", c.path)
+	}
+
+	return removed, nil
+}
+
+// Close closes cache file
+func (c *Cache) Close() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.file == nil {
+		return nil
+	}
+
+	return c.file.Close()
+}
@@ -0,0 +1,146 @@
+package translate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "cache.jsonl")
+
+	c, err := OpenCache(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := c.Get("comfy", "ja", "en", "", "テスト"); ok {
+		t.Error("empty cache returned entry")
+	}
+
+	c.Put("comfy", "ja", "en", "", " テスト", "Test")
+	c.Put("comfy", "ja", "en", "", "ｶﾀｶﾅ", "Katakana")
+	c.Put("rest", "ja", "en", "", "テスト", "Exam")
+	c.Close()
+
+	c, err = OpenCache(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	tests := []struct {
+		backend, str, want string
+		ok                 bool
+	}{
+		{"comfy", "テスト", "Test", true},
+		{"rest", "テスト\n", "Exam", true},
+		{"comfy", "カタカナ", "Katakana", true},
+		{"other", "テスト", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := c.Get(tt.backend, "ja", "en", "", tt.str)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("Get(%q, %q) = %q, %v, want %q, %v", tt.backend, tt.str, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	removed, err := c.Prune(func(e CacheEntry) bool { return e.Backend == "rest" })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if removed != 1 || c.Stats().Entries != 2 {
+		t.Errorf("Prune() removed %d entries, left %d", removed, c.Stats().Entries)
+	}
+
+	c.Put("comfy", "ja", "en", "", "新しい", "New")
+
+	if _, ok := c.Get("comfy", "ja", "en", "", "新しい"); !ok {
+		t.Error("entry added after prune is missing")
+	}
+}
+
+func TestCacheContext(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "cache.jsonl")
+
+	c, err := OpenCache(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := ContextHash([]string{"どこへ行く？"})
+	second := ContextHash([]string{"誰が来た？"})
+
+	c.Put("rest", "ja", "en", first, "あいつだ", "Over there")
+	c.Put("rest", "ja", "en", second, "あいつだ", "That guy")
+	c.Close()
+
+	c, err = OpenCache(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	tests := []struct {
+		context string
+		want    string
+		ok      bool
+	}{
+		{first, "Over there", true},
+		{second, "That guy", true},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := c.Get("rest", "ja", "en", tt.context, "あいつだ")
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Get(%q) = %q, %v, want %q, %v", tt.context, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCacheReadOnly(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "database", "cache.jsonl")
+
+	c, err := OpenCacheReadOnly(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.Put("comfy", "ja", "en", "", "テスト", "Test")
+
+	if _, ok := c.Get("comfy", "ja", "en", "", "テスト"); !ok {
+		t.Error("entry added to read only cache is missing")
+	}
+
+	if _, err := c.Prune(nil); err == nil {
+		t.Error("expected error for pruning read only cache")
+	}
+
+	c.Close()
+
+	if _, err := os.Stat(filepath.Dir(path)); !os.IsNotExist(err) {
+		t.Errorf("read only cache created %s", filepath.Dir(path))
+	}
+}
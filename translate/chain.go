@@ -0,0 +1,226 @@
+package translate
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultMaxInFlight    = 64
+	defaultMaxConnections = 16
+)
+
+// backendPool sends requests to one of the backends in the chain
+type backendPool struct {
+	name string
+
+	pool  *requestPool
+	batch *batcher
+
+	cached      bool
+	withContext bool
+
+	validated       bool // Output is checked by validation rules
+	validateRetries int  // Attempts after the first translation fails validation
+}
+
+func newBackendPool(name string, opts Options) (*backendPool, error) {
+	factory, err := getBackend(name)
+	if err != nil {
+		return nil, err
+	}
+
+	maxInFlight := opts.MaxInFlight
+	if maxInFlight < 1 {
+		maxInFlight = defaultMaxInFlight
+	}
+
+	connections := opts.MaxConnections
+	if connections < 1 {
+		connections = defaultMaxConnections
+	}
+
+	if connections > maxInFlight {
+		connections = maxInFlight
+	}
+
+	opts.transport = newHTTPTransport(connections)
+
+	workers := make([]*worker, 0, connections)
+
+	for i := 0; i < connections; i++ {
+		t, err := factory(opts)
+		if err != nil {
+			for _, w := range workers {
+				w.translator.Close()
+			}
+
+			return nil, errors.Wrapf(err, "failed to start %q translation backend", name)
+		}
+
+		workers = append(workers, &worker{
+			translator: t,
+			retries:    opts.Retries,
+			retryDelay: time.Duration(opts.RetryDelay),
+			timeout:    time.Duration(opts.Timeout),
+		})
+	}
+
+	b := &backendPool{
+		name: name,
+		pool: newPool(workers, maxInFlight, opts.RequestsPerSecond),
+
+		// Offline backends are instant, no point in filling cache with their output
+		cached: !isOffline(name),
+
+		// Offline backends don't translate, their output would never pass
+		validated:       !isOffline(name),
+		validateRetries: opts.ValidateRetries,
+	}
+
+	_, canBatch := workers[0].translator.(BatchTranslator)
+	ct, ok := workers[0].translator.(ContextTranslator)
+	b.withContext = ok && ct.SupportsContext()
+
+	if canBatch && opts.BatchSize > 1 {
+		b.batch = newBatcher(opts.BatchSize, time.Duration(opts.BatchDelay), b.pool.Process)
+	}
+
+	return b, nil
+}
+
+// Process returns cached translation or asks the backend for it. Translation is cleaned up and
+// requested again if it fails validation, invalidTranslation is returned if it never passes
+func (b *backendPool) Process(ctx context.Context, request Request) (string, error) {
+	useCache := cache != nil && b.cached
+
+	if !b.withContext {
+		request.Context = nil
+	}
+
+	// Same line can be translated differently depending on preceding dialogue
+	contextHash := ContextHash(request.Context)
+
+	if useCache {
+		if out, ok := cache.Get(b.name, request.From, request.To, contextHash, request.Text); ok {
+			out = cleanTranslation(out)
+
+			if b.validate(request.Text, out) == nil {
+				return out, nil
+			}
+		}
+	}
+
+	var invalid *invalidTranslation
+
+	for attempt := 0; attempt <= b.validateRetries; attempt++ {
+		out, err := b.translate(ctx, request)
+		if err != nil || len(out) < 1 {
+			return "", err
+		}
+
+		out = cleanTranslation(out)
+
+		if err := b.validate(request.Text, out); err != nil {
+			log.Warnf("%s backend returned bad translation (%v) for %q: %q", b.name, err, request.Text, out)
+
+			// Deterministic backend returns the same text every time, asking again won't help
+			if invalid != nil && invalid.translation == out {
+				break
+			}
+
+			invalid = &invalidTranslation{translation: out, reason: err}
+			continue
+		}
+
+		if useCache {
+			if err := cache.Put(b.name, request.From, request.To, contextHash, request.Text, out); err != nil {
+				log.Error(err)
+			}
+		}
+
+		return out, nil
+	}
+
+	return "", invalid
+}
+
+// translate sends request to the backend
+func (b *backendPool) translate(ctx context.Context, request Request) (string, error) {
+	var result workerResult
+
+	if b.batch != nil {
+		result = b.batch.Process(ctx, request)
+	} else {
+		result = b.pool.Process(ctx, request).(workerResult)
+	}
+
+	if result.err != nil {
+		return "", errors.Wrapf(result.err, "%s backend failed to translate %q", b.name, request.Text)
+	}
+
+	return result.response.TranslationText, nil
+}
+
+func (b *backendPool) validate(original, translation string) error {
+	if !b.validated {
+		return nil
+	}
+
+	return validate(original, translation)
+}
+
+// Close stops collecting batches and closes backend connections
+func (b *backendPool) Close() {
+	if b.batch != nil {
+		b.batch.Close()
+	}
+
+	b.pool.Close()
+}
+
+// processChain tries backends in order until one of them returns valid translation
+func processChain(ctx context.Context, request Request) (string, error) {
+	var lastErr error
+	var invalid *invalidTranslation
+
+	for _, b := range backendPools {
+		out, err := b.Process(ctx, request)
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+
+		if inv, ok := err.(*invalidTranslation); ok {
+			log.Warnf("Trying next backend: %s backend %v", b.name, inv)
+
+			if invalid == nil {
+				invalid = inv
+			}
+
+			continue
+		}
+
+		if err != nil {
+			log.Warnf("Trying next backend: %v", err)
+			lastErr = err
+			continue
+		}
+
+		if len(out) < 1 {
+			log.Warnf("%s backend returned empty string for %q", b.name, request.Text)
+			continue
+		}
+
+		return out, nil
+	}
+
+	// Bad translation is still better than none, caller decides what to do with it
+	if invalid != nil {
+		return "", invalid
+	}
+
+	return "", lastErr
+}
@@ -0,0 +1,102 @@
+package translate
+
+import (
+	"context"
+	"testing"
+
+	"gitgud.io/softashell/rpgmaker-patch-translator/statictl"
+)
+
+func init() {
+	Register("test-fail", func(opts Options) (Translator, error) {
+		return &flakyTranslator{failures: 1 << 30}, nil
+	})
+	Register("test-empty", mockFactory(func(s string) string { return "" }))
+}
+
+func TestChainFallback(t *testing.T) {
+	tests := []struct {
+		backends string
+		want     string
+		wantErr  bool
+	}{
+		{"upper", "ABC", false},
+		{"test-empty,upper", "ABC", false},
+		{"test-fail,reverse", "cba", false},
+		{"test-fail,test-empty,upper", "ABC", false},
+		{"test-empty", "", false},
+		{"test-fail", "", true},
+	}
+
+	for _, tt := range tests {
+		err := Init(Options{Backend: tt.backends, Retries: 0})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := process(context.Background(), Request{Text: "abc"})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v, wantErr %v", tt.backends, err, tt.wantErr)
+		}
+
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.backends, got, tt.want)
+		}
+
+		Close()
+	}
+}
+
+func TestChainCancelled(t *testing.T) {
+	err := Init(Options{Backend: "test-fail,upper"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Cancelled request shouldn't fall through to the next backend
+	got, err := process(ctx, Request{Text: "abc"})
+	if err != context.Canceled || got != "" {
+		t.Errorf("got %q, %v, want context.Canceled", got, err)
+	}
+}
+
+func TestPickCandidate(t *testing.T) {
+	glossary = statictl.Glossary{"勇者": "Hero"}
+	defer func() { glossary = nil }()
+
+	candidates := []Candidate{
+		{Backend: "a", Error: "timeout"},
+		{Backend: "b", Text: "The brave came"},
+		{Backend: "c", Text: "The hero came here"},
+		{Backend: "d", Text: ""},
+		{Backend: "e", Text: "Hero came"},
+	}
+
+	tests := []struct {
+		policy string
+		want   string
+	}{
+		{PickFirst, "b"},
+		{PickShortest, "e"},
+		{PickGlossary, "c"},
+	}
+
+	for _, tt := range tests {
+		got, ok := pickCandidate(tt.policy, "勇者が来た", candidates)
+		if !ok || got.Backend != tt.want {
+			t.Errorf("%s policy picked %q, want %q", tt.policy, got.Backend, tt.want)
+		}
+	}
+
+	if _, ok := pickCandidate(PickFirst, "勇者", candidates[:1]); ok {
+		t.Error("picked candidate that failed")
+	}
+
+	if err := checkComparePolicy("longest"); err == nil {
+		t.Error("expected error for unknown policy")
+	}
+}
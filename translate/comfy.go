@@ -0,0 +1,125 @@
+package translate
+
+import (
+	"context"
+	"net/rpc"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	Register("comfy", newComfyWorker)
+}
+
+// ComfyWorker talks to comfy-translator over net/rpc
+type ComfyWorker struct {
+	address string
+
+	mutex  sync.Mutex
+	client *rpc.Client
+}
+
+func newComfyWorker(opts Options) (Translator, error) {
+	w := &ComfyWorker{
+		address: opts.Address,
+	}
+
+	if len(w.address) < 1 {
+		w.address = "127.0.0.1:3000"
+	}
+
+	if _, err := w.connect(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// connect returns current client or dials a new one if previous connection was dropped
+func (w *ComfyWorker) connect() (*rpc.Client, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.client != nil {
+		return w.client, nil
+	}
+
+	client, err := rpc.DialHTTP("tcp", w.address)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to connect with translation service at %s", w.address)
+	}
+
+	w.client = client
+
+	return client, nil
+}
+
+// disconnect drops broken client so next request reconnects
+func (w *ComfyWorker) disconnect(client *rpc.Client) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.client == client {
+		w.client.Close()
+		w.client = nil
+	}
+}
+
+func (w *ComfyWorker) Translate(ctx context.Context, req Request) (Response, error) {
+	client, err := w.connect()
+	if err != nil {
+		return Response{}, err
+	}
+
+	reply, err := comfyTranslate(ctx, client, req)
+	if ctx.Err() != nil {
+		// Connection is kept for other requests
+		return Response{}, ctx.Err()
+	}
+
+	if err != nil {
+		if _, ok := errors.Cause(err).(rpc.ServerError); !ok {
+			w.disconnect(client)
+		}
+
+		return Response{}, err
+	}
+
+	return reply, nil
+}
+
+func (w *ComfyWorker) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.client == nil {
+		return nil
+	}
+
+	err := w.client.Close()
+	w.client = nil
+
+	return err
+}
+
+// comfyTranslate stops waiting for reply when ctx is done, net/rpc calls can't be cancelled so
+// abandoned call finishes in the background without affecting other requests on the same connection
+func comfyTranslate(ctx context.Context, client *rpc.Client, req Request) (Response, error) {
+	var reply Response
+
+	call := client.Go("Comfy.Translate", req, &reply, make(chan *rpc.Call, 1))
+
+	select {
+	case <-call.Done:
+	case <-ctx.Done():
+		// Reply can still be written to by abandoned call
+		return Response{}, ctx.Err()
+	}
+
+	if call.Error != nil {
+		return reply, errors.Wrap(call.Error, "translation service error")
+	}
+
+	return reply, nil
+}
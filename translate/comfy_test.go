@@ -0,0 +1,87 @@
+package translate
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/rpc"
+	"testing"
+	"time"
+)
+
+// Comfy is a fake comfy-translator service that never replies to "テスト"
+type Comfy struct {
+	release chan struct{}
+}
+
+func (c *Comfy) Translate(req Request, reply *Response) error {
+	if req.Text == "テスト" {
+		<-c.release
+	}
+
+	reply.TranslationText = "test"
+
+	return nil
+}
+
+func TestComfyCancel(t *testing.T) {
+	service := &Comfy{release: make(chan struct{})}
+	defer close(service.release)
+
+	server := rpc.NewServer()
+	if err := server.Register(service); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	mux := http.NewServeMux()
+	mux.Handle(rpc.DefaultRPCPath, server)
+
+	go http.Serve(l, mux)
+
+	tr, err := newComfyWorker(Options{Address: l.Addr().String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tr.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+
+	_, err = tr.Translate(ctx, Request{Text: "テスト"})
+	if err != context.DeadlineExceeded {
+		t.Errorf("Translate() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("cancelled request kept waiting for %s", elapsed)
+	}
+
+	w := tr.(*ComfyWorker)
+	if w.client == nil {
+		t.Fatal("connection was dropped after cancelled request")
+	}
+
+	client := w.client
+
+	// Other requests keep using the same connection
+	reply, err := tr.Translate(context.Background(), Request{Text: "はい"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reply.TranslationText != "test" {
+		t.Errorf("Translate() = %q, want %q", reply.TranslationText, "test")
+	}
+
+	if w.client != client {
+		t.Error("connection was replaced after cancelled request")
+	}
+}
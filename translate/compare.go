@@ -0,0 +1,143 @@
+package translate
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"unicode/utf8"
+)
+
+// Policies used to pick one of translations in compare mode
+const (
+	PickFirst    = "first"    // First backend in the list that succeeded
+	PickShortest = "shortest" // Shortest translation
+	PickGlossary = "glossary" // Translation that uses most terms from glossary
+)
+
+// Candidate is translation returned by one of the compared backends
+type Candidate struct {
+	Backend string `json:"backend"`
+	Text    string `json:"text,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Comparison holds every candidate for the same text
+type Comparison struct {
+	Text       string      `json:"text"`
+	Candidates []Candidate `json:"candidates"`
+	Picked     string      `json:"picked"`
+}
+
+var comparisons = struct {
+	sync.Mutex
+	list []Comparison
+}{}
+
+func checkComparePolicy(policy string) error {
+	switch policy {
+	case "", PickFirst, PickShortest, PickGlossary:
+		return nil
+	}
+
+	return fmt.Errorf("unknown compare policy %q", policy)
+}
+
+// processCompare asks every backend at once and picks one of the results
+func processCompare(ctx context.Context, request Request) (string, error) {
+	candidates := make([]Candidate, len(backendPools))
+	invalid := make([]*invalidTranslation, len(backendPools))
+
+	var wg sync.WaitGroup
+
+	for i, b := range backendPools {
+		wg.Add(1)
+
+		go func(i int, b *backendPool) {
+			defer wg.Done()
+
+			out, err := b.Process(ctx, request)
+
+			candidates[i] = Candidate{Backend: b.name, Text: out}
+			if err != nil {
+				candidates[i].Error = err.Error()
+			}
+
+			if inv, ok := err.(*invalidTranslation); ok {
+				candidates[i].Text = inv.translation
+				invalid[i] = inv
+			}
+		}(i, b)
+	}
+
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+
+	picked, ok := pickCandidate(comparePolicy, request.Text, candidates)
+
+	comparisons.Lock()
+	comparisons.list = append(comparisons.list, Comparison{
+		Text:       request.Text,
+		Candidates: candidates,
+		Picked:     picked.Backend,
+	})
+	comparisons.Unlock()
+
+	if !ok {
+		for _, inv := range invalid {
+			if inv != nil {
+				return "", inv
+			}
+		}
+
+		for _, c := range candidates {
+			if len(c.Error) < 1 {
+				return "", nil // Nothing failed, translation is just empty
+			}
+		}
+
+		return "", fmt.Errorf("every backend failed to translate %q", request.Text)
+	}
+
+	return picked.Text, nil
+}
+
+func pickCandidate(policy, original string, candidates []Candidate) (Candidate, bool) {
+	var best Candidate
+	var bestScore int
+	var found bool
+
+	for _, c := range candidates {
+		if len(c.Error) > 0 || len(c.Text) < 1 {
+			continue
+		}
+
+		var score int
+
+		switch policy {
+		case PickShortest:
+			score = -utf8.RuneCountInString(c.Text)
+		case PickGlossary:
+			score = glossary.Hits(original, c.Text)
+		}
+
+		// Earlier backends win ties
+		if !found || score > bestScore {
+			best = c
+			bestScore = score
+			found = true
+		}
+	}
+
+	return best, found
+}
+
+// Comparisons returns every comparison made so far
+func Comparisons() []Comparison {
+	comparisons.Lock()
+	defer comparisons.Unlock()
+
+	return append([]Comparison(nil), comparisons.list...)
+}
@@ -0,0 +1,62 @@
+package translate
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// rateLimiter spaces out requests evenly, nil limiter doesn't limit anything
+type rateLimiter struct {
+	mutex    sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newRateLimiter(perSecond float64) *rateLimiter {
+	if perSecond <= 0 {
+		return nil
+	}
+
+	return &rateLimiter{
+		interval: time.Duration(float64(time.Second) / perSecond),
+	}
+}
+
+// Wait blocks until next request is allowed or ctx is done
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return ctx.Err()
+	}
+
+	l.mutex.Lock()
+
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+
+	wait := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+
+	l.mutex.Unlock()
+
+	return sleep(ctx, wait)
+}
+
+// sleep pauses for d, returning early with an error if ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
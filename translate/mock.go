@@ -0,0 +1,94 @@
+package translate
+
+import (
+	"context"
+	"strings"
+)
+
+// Backends that don't need any service, useful for testing the rest of the pipeline
+var offlineBackends = map[string]Factory{
+	"echo":    mockFactory(func(s string) string { return s }),
+	"upper":   mockFactory(strings.ToUpper),
+	"reverse": mockFactory(reverseWords),
+	"pseudo":  newPseudoTranslator,
+}
+
+func init() {
+	for name, factory := range offlineBackends {
+		Register(name, factory)
+	}
+}
+
+func isOffline(name string) bool {
+	_, ok := offlineBackends[strings.TrimSpace(name)]
+	return ok
+}
+
+func allOffline(names []string) bool {
+	for _, name := range names {
+		if !isOffline(name) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func mockFactory(fn func(string) string) Factory {
+	return func(opts Options) (Translator, error) {
+		return &mockTranslator{fn: fn}, nil
+	}
+}
+
+// mockTranslator translates by applying fn to the text
+type mockTranslator struct {
+	fn func(string) string
+}
+
+func (t *mockTranslator) Translate(ctx context.Context, req Request) (Response, error) {
+	if err := ctx.Err(); err != nil {
+		return Response{}, err
+	}
+
+	return Response{
+		Text:            req.Text,
+		From:            req.From,
+		To:              req.To,
+		TranslationText: t.fn(req.Text),
+	}, nil
+}
+
+func (t *mockTranslator) TranslateBatch(ctx context.Context, reqs []Request) ([]Response, error) {
+	responses := make([]Response, len(reqs))
+
+	for i, req := range reqs {
+		var err error
+
+		responses[i], err = t.Translate(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return responses, nil
+}
+
+func (t *mockTranslator) Close() error {
+	return nil
+}
+
+// reverseWords reverses characters in every space separated word
+func reverseWords(s string) string {
+	words := strings.Split(s, " ")
+
+	for i, w := range words {
+		r := []rune(w)
+		for a, b := 0, len(r)-1; a < b; a, b = a+1, b-1 {
+			r[a], r[b] = r[b], r[a]
+		}
+
+		words[i] = string(r)
+	}
+
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,40 @@
+package translate
+
+import (
+	"context"
+	"testing"
+)
+
+func TestOfflineBackends(t *testing.T) {
+	tests := []struct {
+		backend string
+		input   string
+		output  string
+	}{
+		{"echo", "こんにちは", "こんにちは"},
+		{"upper", "hello world", "HELLO WORLD"},
+		{"reverse", "こんにちは", "はちにんこ"},
+		{"reverse", "hello  world", "olleh  dlrow"},
+	}
+
+	for _, tt := range tests {
+		factory, err := getBackend(tt.backend)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tr, err := factory(Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := tr.Translate(context.Background(), Request{Text: tt.input})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.TranslationText != tt.output {
+			t.Errorf("%s backend for input %q expected %q got %q", tt.backend, tt.input, tt.output, resp.TranslationText)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package translate
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"gitgud.io/softashell/rpgmaker-patch-translator/statictl"
+)
+
+// Options configures translation backends
+type Options struct {
+	Backend string `json:"backend"` // Name of registered backend, or comma separated list of fallbacks
+	Compare string `json:"compare"` // Ask every backend and pick result by policy instead of using the first that works
+
+	GlossaryFile string            `json:"glossaryFile"` // Terms that always use the same translation
+	Glossary     statictl.Glossary `json:"-"`            // Loaded from GlossaryFile by the caller, shared with static translation
+
+	Validate        string  `json:"validate"`        // Comma separated list of validation rules, empty disables validation
+	ValidateRetries int     `json:"validateRetries"` // Attempts per backend after translation fails validation
+	MaxLengthRatio  float64 `json:"maxLengthRatio"`  // Max length of translation compared to original checked by ratio rule
+
+	From string `json:"from"` // Source language
+	To   string `json:"to"`   // Target language
+
+	Address    string   `json:"address"`    // Address of comfy-translator service
+	Retries    int      `json:"retries"`    // Attempts per request after the first one fails
+	RetryDelay Duration `json:"retryDelay"` // Delay before first retry, doubled after every failure
+	Timeout    Duration `json:"timeout"`    // Limit for a single attempt of a request, 0 waits forever
+
+	MaxInFlight       int     `json:"maxInFlight"`       // Max amount of requests processed at once
+	MaxConnections    int     `json:"maxConnections"`    // Max amount of connections to backend, shared between requests
+	RequestsPerSecond float64 `json:"requestsPerSecond"` // Max amount of requests sent every second, 0 is unlimited
+
+	BatchSize  int      `json:"batchSize"`  // Max texts sent in one request to backends that support it, 1 disables batching
+	BatchDelay Duration `json:"batchDelay"` // How long to wait for more texts before sending incomplete batch
+
+	PseudoRatio float64 `json:"pseudoRatio"` // How many times longer pseudo backend output is compared to input
+
+	CacheFile     string `json:"cacheFile"` // Translations are stored here and reused on next run, empty disables cache
+	CacheReadOnly bool   `json:"-"`         // Cached translations are used but new ones aren't saved
+
+	RESTURL     string            `json:"restUrl"`     // Endpoint that receives POST requests
+	RESTHeaders map[string]string `json:"restHeaders"` // Extra headers sent with every request
+	RESTField   string            `json:"restField"`   // Dot separated path to translated text in response
+	RESTBatch   string            `json:"restBatch"`   // Dot separated path to array of translations in batch response, empty if it's the response itself
+
+	transport *http.Transport // Shared by every worker of one backend pool so connection limit applies to all of them
+}
+
+// Duration is time.Duration that can be read from config as "1m30s"
+type Duration time.Duration
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(v)
+
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
@@ -0,0 +1,82 @@
+package translate
+
+import (
+	"context"
+	"sync/atomic"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// requestPool limits how many requests are sent at once and how often, requests
+// are spread over workers that each own one backend connection
+type requestPool struct {
+	workers []*worker
+	next    uint64
+
+	slots   chan struct{}
+	limiter *rateLimiter
+
+	queued   int64
+	inFlight int64
+}
+
+func newPool(workers []*worker, maxInFlight int, perSecond float64) *requestPool {
+	if maxInFlight < 1 {
+		maxInFlight = 1
+	}
+
+	p := &requestPool{
+		workers: workers,
+		slots:   make(chan struct{}, maxInFlight),
+		limiter: newRateLimiter(perSecond),
+	}
+
+	for _, w := range workers {
+		w.limiter = p.limiter
+	}
+
+	return p
+}
+
+// Process waits for a free slot and runs payload on the next worker
+func (p *requestPool) Process(ctx context.Context, payload interface{}) interface{} {
+	atomic.AddInt64(&p.queued, 1)
+
+	select {
+	case p.slots <- struct{}{}:
+		atomic.AddInt64(&p.queued, -1)
+	case <-ctx.Done():
+		atomic.AddInt64(&p.queued, -1)
+		return workerResult{err: ctx.Err()}
+	}
+
+	atomic.AddInt64(&p.inFlight, 1)
+	defer func() {
+		atomic.AddInt64(&p.inFlight, -1)
+		<-p.slots
+	}()
+
+	n := atomic.AddUint64(&p.next, 1)
+	w := p.workers[n%uint64(len(p.workers))]
+
+	return w.Process(ctx, payload)
+}
+
+// QueueLength returns amount of requests waiting for a free slot
+func (p *requestPool) QueueLength() int64 {
+	return atomic.LoadInt64(&p.queued)
+}
+
+// InFlight returns amount of requests currently being processed
+func (p *requestPool) InFlight() int64 {
+	return atomic.LoadInt64(&p.inFlight)
+}
+
+// Close closes every backend connection
+func (p *requestPool) Close() {
+	for _, w := range p.workers {
+		if err := w.translator.Close(); err != nil {
+			log.Error("Failed to close translator:", err)
+		}
+	}
+}
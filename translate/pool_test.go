@@ -0,0 +1,79 @@
+package translate
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type slowTranslator struct {
+	active    int64
+	maxActive int64
+}
+
+func (t *slowTranslator) Translate(ctx context.Context, req Request) (Response, error) {
+	n := atomic.AddInt64(&t.active, 1)
+	defer atomic.AddInt64(&t.active, -1)
+
+	for {
+		max := atomic.LoadInt64(&t.maxActive)
+		if n <= max || atomic.CompareAndSwapInt64(&t.maxActive, max, n) {
+			break
+		}
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	return Response{TranslationText: req.Text}, nil
+}
+
+func (t *slowTranslator) Close() error {
+	return nil
+}
+
+func TestPoolMaxInFlight(t *testing.T) {
+	tr := &slowTranslator{}
+
+	p := newPool([]*worker{{translator: tr}, {translator: tr}}, 3, 0)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			p.Process(context.Background(), Request{Text: "test"})
+		}()
+	}
+
+	wg.Wait()
+
+	if tr.maxActive > 3 {
+		t.Errorf("%d requests were processed at once, limit is 3", tr.maxActive)
+	}
+
+	if p.QueueLength() != 0 || p.InFlight() != 0 {
+		t.Errorf("pool isn't empty after all requests finished: %d queued, %d in flight", p.QueueLength(), p.InFlight())
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	l := newRateLimiter(100)
+
+	start := time.Now()
+
+	for i := 0; i < 11; i++ {
+		l.Wait(context.Background())
+	}
+
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("11 requests at 100 per second took only %s", elapsed)
+	}
+
+	// Unlimited
+	var none *rateLimiter
+	none.Wait(context.Background())
+}
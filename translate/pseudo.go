@@ -0,0 +1,81 @@
+package translate
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+const (
+	defaultPseudoRatio = 2.5
+	pseudoWordLength   = 6
+
+	// Kept as they are, anything else could turn into an escape code
+	pseudoPunctuation = ".,!?'-"
+)
+
+var (
+	pseudoLetters = []rune("áàâäãåéèêëíìîïóòôöõúùûüýÿñçðøþßĝĥĵķļŝŧŵź")
+
+	pseudoAccents = map[rune]rune{
+		'a': 'á', 'b': 'ƀ', 'c': 'ç', 'd': 'ð', 'e': 'é', 'f': 'ƒ', 'g': 'ĝ',
+		'h': 'ĥ', 'i': 'í', 'j': 'ĵ', 'k': 'ķ', 'l': 'ļ', 'm': 'ɱ', 'n': 'ñ',
+		'o': 'ö', 'p': 'þ', 'q': 'ǫ', 'r': 'ŕ', 's': 'š', 't': 'ŧ', 'u': 'ü',
+		'v': 'ṽ', 'w': 'ŵ', 'x': 'ẋ', 'y': 'ý', 'z': 'ž',
+		'A': 'Á', 'B': 'Ɓ', 'C': 'Ç', 'D': 'Ð', 'E': 'É', 'F': 'Ƒ', 'G': 'Ĝ',
+		'H': 'Ĥ', 'I': 'Í', 'J': 'Ĵ', 'K': 'Ķ', 'L': 'Ļ', 'M': 'Ṁ', 'N': 'Ñ',
+		'O': 'Ö', 'P': 'Þ', 'Q': 'Ǫ', 'R': 'Ŕ', 'S': 'Š', 'T': 'Ŧ', 'U': 'Ü',
+		'V': 'Ṽ', 'W': 'Ŵ', 'X': 'Ẋ', 'Y': 'Ý', 'Z': 'Ž',
+	}
+)
+
+// newPseudoTranslator creates backend that replaces text with accented latin
+// characters, output is longer than input by configured ratio
+func newPseudoTranslator(opts Options) (Translator, error) {
+	ratio := opts.PseudoRatio
+	if ratio <= 0 {
+		ratio = defaultPseudoRatio
+	}
+
+	return &mockTranslator{
+		fn: func(s string) string {
+			return pseudoLocalize(s, ratio)
+		},
+	}, nil
+}
+
+// pseudoLocalize is deterministic so repeated runs produce the same patch
+func pseudoLocalize(s string, ratio float64) string {
+	src := []rune(strings.TrimSpace(s))
+	if len(src) < 1 {
+		return s
+	}
+
+	hasSpaces := strings.ContainsAny(string(src), " ")
+	length := int(math.Ceil(float64(len(src)) * ratio))
+
+	out := make([]rune, 0, length)
+
+	for i := 0; len(out) < length; i++ {
+		r := src[i%len(src)]
+
+		// Source without spaces gets split in words so lines can be broken
+		if !hasSpaces && len(out)%(pseudoWordLength+1) == pseudoWordLength {
+			out = append(out, ' ')
+			continue
+		}
+
+		switch {
+		case unicode.IsSpace(r):
+			out = append(out, ' ')
+		case pseudoAccents[r] != 0:
+			out = append(out, pseudoAccents[r])
+		case strings.ContainsRune(pseudoPunctuation, r):
+			out = append(out, r)
+		default:
+			out = append(out, pseudoLetters[(int(r)+i)%len(pseudoLetters)])
+		}
+	}
+
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,42 @@
+package translate
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"gitgud.io/softashell/rpgmaker-patch-translator/text"
+)
+
+func TestPseudoLocalize(t *testing.T) {
+	tests := []struct {
+		input string
+		ratio float64
+	}{
+		{"こんにちは", 2},
+		{"勇者が来た！", 3},
+		{"Hello world", 1.5},
+		{"あ", 1},
+	}
+
+	for _, tt := range tests {
+		out := pseudoLocalize(tt.input, tt.ratio)
+
+		want := int(float64(utf8.RuneCountInString(tt.input))*tt.ratio + 0.999)
+		if n := utf8.RuneCountInString(out); n < want-1 || n > want {
+			t.Errorf("For input %q with ratio %v expected %d characters got %d: %q", tt.input, tt.ratio, want, n, out)
+		}
+
+		if text.ShouldTranslate(out) {
+			t.Errorf("For input %q output still needs translation: %q", tt.input, out)
+		}
+
+		if out != pseudoLocalize(tt.input, tt.ratio) {
+			t.Errorf("For input %q output isn't deterministic", tt.input)
+		}
+	}
+
+	if out := pseudoLocalize("あいうえおかきくけこ", 2); !strings.Contains(out, " ") {
+		t.Errorf("Long text without spaces can't be broken into lines: %q", out)
+	}
+}
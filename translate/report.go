@@ -0,0 +1,33 @@
+package translate
+
+import (
+	"sync"
+)
+
+// Flag marks translation that should be checked by a human
+type Flag struct {
+	Text        string
+	Translation string
+	Reason      string
+}
+
+var flags = struct {
+	sync.Mutex
+	list []Flag
+}{}
+
+// AddFlag marks translation of text for review, flags are listed in flagged.txt after translation
+func AddFlag(text, translation, reason string) {
+	flags.Lock()
+	defer flags.Unlock()
+
+	flags.list = append(flags.list, Flag{text, translation, reason})
+}
+
+// Flags returns every translation flagged so far
+func Flags() []Flag {
+	flags.Lock()
+	defer flags.Unlock()
+
+	return append([]Flag(nil), flags.list...)
+}
@@ -0,0 +1,220 @@
+package translate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	Register("rest", newRESTTranslator)
+}
+
+// newHTTPTransport returns transport that keeps at most connections open to the service, 0 is unlimited
+func newHTTPTransport(connections int) *http.Transport {
+	return &http.Transport{
+		MaxConnsPerHost:     connections,
+		MaxIdleConnsPerHost: connections,
+		IdleConnTimeout:     90 * time.Second,
+	}
+}
+
+// RESTTranslator posts requests as JSON to any HTTP translation service
+type RESTTranslator struct {
+	client  *http.Client
+	url     string
+	headers map[string]string
+	field   []string
+	batch   []string
+}
+
+func newRESTTranslator(opts Options) (Translator, error) {
+	if len(opts.RESTURL) < 1 {
+		return nil, fmt.Errorf("rest backend requires an url")
+	}
+
+	field := opts.RESTField
+	if len(field) < 1 {
+		field = "translationText"
+	}
+
+	transport := opts.transport
+	if transport == nil {
+		transport = newHTTPTransport(opts.MaxConnections)
+	}
+
+	t := &RESTTranslator{
+		client:  &http.Client{Transport: transport},
+		url:     opts.RESTURL,
+		headers: opts.RESTHeaders,
+		field:   strings.Split(field, "."),
+	}
+
+	if len(opts.RESTBatch) > 0 {
+		t.batch = strings.Split(opts.RESTBatch, ".")
+	}
+
+	return t, nil
+}
+
+func (t *RESTTranslator) Translate(ctx context.Context, req Request) (Response, error) {
+	reply, err := t.post(ctx, req)
+	if err != nil {
+		return Response{}, err
+	}
+
+	out, err := lookupField(reply, t.field)
+	if err != nil {
+		return Response{}, err
+	}
+
+	return Response{
+		Text:            req.Text,
+		From:            req.From,
+		To:              req.To,
+		TranslationText: out,
+	}, nil
+}
+
+// TranslateBatch posts array of requests, service has to reply with an array of the same length
+func (t *RESTTranslator) TranslateBatch(ctx context.Context, reqs []Request) ([]Response, error) {
+	reply, err := t.post(ctx, reqs)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err = lookupValue(reply, t.batch)
+	if err != nil {
+		return nil, err
+	}
+
+	list, ok := reply.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("batch translation response is not an array")
+	}
+
+	if len(list) != len(reqs) {
+		return nil, fmt.Errorf("translation service returned %d translations for %d texts", len(list), len(reqs))
+	}
+
+	responses := make([]Response, len(reqs))
+
+	for i, req := range reqs {
+		out, err := lookupField(list[i], t.field)
+		if err != nil {
+			return nil, err
+		}
+
+		responses[i] = Response{
+			Text:            req.Text,
+			From:            req.From,
+			To:              req.To,
+			TranslationText: out,
+		}
+	}
+
+	return responses, nil
+}
+
+// post sends payload as JSON and returns decoded reply
+func (t *RESTTranslator) post(ctx context.Context, payload interface{}) (interface{}, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", t.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json")
+
+	for k, v := range t.headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	resp, err := t.client.Do(httpReq)
+	if err != nil {
+		return nil, errors.Wrap(err, "translation request failed")
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read translation response")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("translation service returned %s: %q", resp.Status, data)
+	}
+
+	var reply interface{}
+	if err := json.Unmarshal(data, &reply); err != nil {
+		return nil, errors.Wrap(err, "failed to decode translation response")
+	}
+
+	return reply, nil
+}
+
+// SupportsContext is true since preceding lines are sent as "context" field,
+// services that don't need it are expected to ignore it
+func (t *RESTTranslator) SupportsContext() bool {
+	return true
+}
+
+func (t *RESTTranslator) Close() error {
+	t.client.CloseIdleConnections()
+
+	return nil
+}
+
+// lookupValue walks decoded JSON following path, numbers are used as array indexes
+func lookupValue(v interface{}, path []string) (interface{}, error) {
+	for _, key := range path {
+		switch node := v.(type) {
+		case map[string]interface{}:
+			next, ok := node[key]
+			if !ok {
+				return nil, fmt.Errorf("field %q missing from translation response", strings.Join(path, "."))
+			}
+
+			v = next
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, fmt.Errorf("index %q out of range in translation response", key)
+			}
+
+			v = node[i]
+		default:
+			return nil, fmt.Errorf("field %q missing from translation response", strings.Join(path, "."))
+		}
+	}
+
+	return v, nil
+}
+
+// lookupField returns string found at path
+func lookupField(v interface{}, path []string) (string, error) {
+	v, err := lookupValue(v, path)
+	if err != nil {
+		return "", err
+	}
+
+	str, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("field %q in translation response is not a string", strings.Join(path, "."))
+	}
+
+	return str, nil
+}
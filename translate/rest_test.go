@@ -0,0 +1,191 @@
+package translate
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRESTTranslator(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"translations": []interface{}{
+					map[string]string{"text": req.From + ">" + req.To + ":" + req.Text},
+				},
+			},
+		})
+	}))
+	defer server.Close()
+
+	tr, err := newRESTTranslator(Options{
+		RESTURL:     server.URL,
+		RESTHeaders: map[string]string{"Authorization": "Bearer secret"},
+		RESTField:   "data.translations.0.text",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := tr.Translate(context.Background(), Request{Text: "テスト", From: "ja", To: "en"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.TranslationText != "ja>en:テスト" {
+		t.Errorf("expected %q got %q", "ja>en:テスト", resp.TranslationText)
+	}
+
+	tr, err = newRESTTranslator(Options{
+		RESTURL:   server.URL,
+		RESTField: "data.translations.0.text",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tr.Translate(context.Background(), Request{Text: "テスト"}); err == nil {
+		t.Error("expected error for unauthorized request")
+	}
+}
+
+func TestRESTConnectionLimit(t *testing.T) {
+	var mutex sync.Mutex
+	var open, maxOpen int
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		json.NewEncoder(w).Encode(map[string]string{"translationText": "test"})
+	}))
+	server.Config.ConnState = func(c net.Conn, state http.ConnState) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		switch state {
+		case http.StateNew:
+			open++
+			if open > maxOpen {
+				maxOpen = open
+			}
+		case http.StateClosed, http.StateHijacked:
+			open--
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	// Workers of one pool share the transport
+	opts := Options{RESTURL: server.URL, transport: newHTTPTransport(2)}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 4; i++ {
+		tr, err := newRESTTranslator(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer tr.Close()
+
+		for j := 0; j < 2; j++ {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				if _, err := tr.Translate(context.Background(), Request{Text: "テスト"}); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+	}
+
+	wg.Wait()
+
+	if maxOpen > 2 {
+		t.Errorf("%d connections were open at once, want at most 2", maxOpen)
+	}
+}
+
+func TestRESTTranslatorBatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqs []Request
+		if err := json.NewDecoder(r.Body).Decode(&reqs); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var out []interface{}
+		for _, req := range reqs {
+			out = append(out, map[string]string{"translationText": "tl:" + req.Text})
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{"results": out})
+	}))
+	defer server.Close()
+
+	tr, err := newRESTTranslator(Options{
+		RESTURL:   server.URL,
+		RESTBatch: "results",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reqs := []Request{{Text: "一"}, {Text: "二"}, {Text: "三"}}
+
+	responses, err := tr.(BatchTranslator).TranslateBatch(context.Background(), reqs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, req := range reqs {
+		if responses[i].TranslationText != "tl:"+req.Text {
+			t.Errorf("expected %q got %q", "tl:"+req.Text, responses[i].TranslationText)
+		}
+	}
+}
+
+func TestLookupField(t *testing.T) {
+	var reply interface{}
+	json.Unmarshal([]byte(`{"a":{"b":[1,"x"]},"n":5}`), &reply)
+
+	tests := []struct {
+		path    []string
+		want    string
+		wantErr bool
+	}{
+		{[]string{"a", "b", "1"}, "x", false},
+		{[]string{"a", "b", "0"}, "", true},
+		{[]string{"a", "b", "2"}, "", true},
+		{[]string{"a", "c"}, "", true},
+		{[]string{"n"}, "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := lookupField(reply, tt.path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("lookupField(%v) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			continue
+		}
+
+		if got != tt.want {
+			t.Errorf("lookupField(%v) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
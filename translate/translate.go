@@ -1,55 +1,108 @@
 package translate
 
 import (
-	"net/http"
-	"net/rpc"
-	"runtime"
+	"context"
 	"strings"
 	"unicode"
 
+	"gitgud.io/softashell/rpgmaker-patch-translator/statictl"
 	"gitgud.io/softashell/rpgmaker-patch-translator/text"
-	"github.com/Jeffail/tunny"
 	log "github.com/sirupsen/logrus"
 )
 
-type translateRequest struct {
-	Text string `json:"text"`
-	From string `json:"from"`
-	To   string `json:"to"`
+// Request is sent to translation backend
+type Request struct {
+	Text    string   `json:"text"`
+	From    string   `json:"from"`
+	To      string   `json:"to"`
+	Context []string `json:"context,omitempty"` // Preceding lines, only sent to backends that support it
 }
 
-type translateResponse struct {
+// Response is returned from translation backend
+type Response struct {
 	Text            string `json:"text"`
 	From            string `json:"from"`
 	To              string `json:"to"`
 	TranslationText string `json:"translationText"`
 }
 
-var httpTransport *http.Transport
-var pool *tunny.Pool
+var (
+	backendPools []*backendPool
+	cache        *Cache
 
-func Init() {
-	httpTransport = &http.Transport{}
+	comparePolicy string
+	glossary      statictl.Glossary
 
-	workerCount := 128 * runtime.NumCPU()
+	fromLanguage = "ja"
+	toLanguage   = "en"
+)
 
-	pool = tunny.New(workerCount, func() tunny.Worker {
-		return newComfyWorker()
-	})
-}
+// Init starts workers for selected translation backends, Options.Backend
+// can be a comma separated list of backends that are tried in order
+func Init(opts Options) error {
+	var err error
+
+	if len(opts.From) > 0 {
+		fromLanguage = opts.From
+	}
 
-func comfyTranslate(client *rpc.Client, req translateRequest) translateResponse {
-	var reply translateResponse
+	if len(opts.To) > 0 {
+		toLanguage = opts.To
+	}
+
+	if err := text.SetSourceLanguage(fromLanguage); err != nil {
+		return err
+	}
 
-	err := client.Call("Comfy.Translate", req, &reply)
-	if err != nil {
-		log.Fatal("translation service error:", err)
+	if err := checkComparePolicy(opts.Compare); err != nil {
+		return err
 	}
 
-	return reply
+	if err := setValidators(opts.Validate); err != nil {
+		return err
+	}
+
+	maxLengthRatio = opts.MaxLengthRatio
+	if maxLengthRatio <= 0 {
+		maxLengthRatio = defaultMaxLengthRatio
+	}
+
+	comparePolicy = opts.Compare
+
+	glossary = opts.Glossary
+
+	names := strings.Split(opts.Backend, ",")
+
+	if len(opts.CacheFile) > 0 && !allOffline(names) {
+		if opts.CacheReadOnly {
+			cache, err = OpenCacheReadOnly(opts.CacheFile)
+		} else {
+			cache, err = OpenCache(opts.CacheFile)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	backendPools = nil
+
+	for _, name := range names {
+		b, err := newBackendPool(strings.TrimSpace(name), opts)
+		if err != nil {
+			Close()
+			return err
+		}
+
+		backendPools = append(backendPools, b)
+	}
+
+	return nil
 }
 
-func String(str string) (string, error) {
+// String translates str, preceding lines of dialogue are used as context by backends that support it.
+// Request is abandoned once ctx is done
+func String(ctx context.Context, str string, preceding []string) (string, error) {
 	if !text.ShouldTranslate(str) {
 		return str, nil
 	}
@@ -58,26 +111,85 @@ func String(str string) (string, error) {
 		return "", nil
 	}
 
-	request := translateRequest{
-		From: "ja",
-		To:   "en",
-		Text: str,
+	request := Request{
+		From:    fromLanguage,
+		To:      toLanguage,
+		Text:    str,
+		Context: preceding,
 	}
 
-	response := pool.Process(request).(translateResponse)
+	out, err := process(ctx, request)
+	if inv, ok := err.(*invalidTranslation); ok {
+		log.Warnf("Every backend failed validation of %q, using %q: %v", str, inv.translation, inv.reason)
+		AddFlag(str, inv.translation, inv.reason.Error())
 
-	out := response.TranslationText
+		return inv.translation, nil
+	} else if err != nil {
+		return "", err
+	}
 
 	if len(out) < 1 {
 		log.Warnf("Translator returned empty string, replacing with original text %q", str)
-		out = str
-	} else {
-		out = cleanTranslation(out)
+		return str, nil
+	}
+
+	// Glossary placeholders are replaced by post translation, lost ones leave terms untranslated
+	if err := checkPlaceholders(str, out); err != nil {
+		log.Warnf("%v in translation of %q: %q", err, str, out)
+		AddFlag(str, out, err.Error())
 	}
 
 	return out, nil
 }
 
+// process sends request to backends, either comparing them or using the first that works
+func process(ctx context.Context, request Request) (string, error) {
+	if len(comparePolicy) > 0 {
+		return processCompare(ctx, request)
+	}
+
+	return processChain(ctx, request)
+}
+
+// QueueLength returns amount of requests waiting for translation and amount of requests in progress
+func QueueLength() (int64, int64) {
+	var queued, inFlight int64
+
+	for _, b := range backendPools {
+		queued += b.pool.QueueLength()
+		inFlight += b.pool.InFlight()
+	}
+
+	return queued, inFlight
+}
+
+// Stats returns usage of translation cache, false if it's disabled
+func Stats() (CacheStats, bool) {
+	if cache == nil {
+		return CacheStats{}, false
+	}
+
+	return cache.Stats(), true
+}
+
+// Close stops translation workers and closes cache
+func Close() error {
+	for _, b := range backendPools {
+		b.Close()
+	}
+
+	backendPools = nil
+
+	if cache != nil {
+		err := cache.Close()
+		cache = nil
+
+		return err
+	}
+
+	return nil
+}
+
 func cleanTranslation(str string) string {
 	// Removes any rune that isn't printable or a space
 	isValid := func(r rune) rune {
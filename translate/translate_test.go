@@ -1,6 +1,9 @@
 package translate
 
-import "testing"
+import (
+	"context"
+	"testing"
+)
 
 func TestCleanTranslatedText(t *testing.T) {
 	type testpair struct {
@@ -47,3 +50,39 @@ func TestCleanTranslatedText(t *testing.T) {
 	}
 
 }
+
+func TestStringPlaceholders(t *testing.T) {
+	tests := []struct {
+		backend string
+		input   string
+		want    string
+		flagged bool
+	}{
+		{"echo", "{T0}が来た", "{T0}が来た", false},
+		{"echo", "{T0}", "{T0}", false},
+		{"reverse", "{T0}が来た", "た来が}0T{", true},
+	}
+
+	for _, tt := range tests {
+		if err := Init(Options{Backend: tt.backend}); err != nil {
+			t.Fatal(err)
+		}
+
+		before := len(Flags())
+
+		got, err := String(context.Background(), tt.input, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got != tt.want {
+			t.Errorf("%s: String(%q) = %q, want %q", tt.backend, tt.input, got, tt.want)
+		}
+
+		if flagged := len(Flags()) > before; flagged != tt.flagged {
+			t.Errorf("%s: String(%q) flagged = %v, want %v", tt.backend, tt.input, flagged, tt.flagged)
+		}
+
+		Close()
+	}
+}
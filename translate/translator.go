@@ -0,0 +1,76 @@
+package translate
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Translator is implemented by every translation backend
+type Translator interface {
+	// Translate sends a single request to the backend and returns its reply,
+	// it should give up once ctx is done
+	Translate(ctx context.Context, req Request) (Response, error)
+
+	// Close releases any resources held by the backend
+	Close() error
+}
+
+// ContextTranslator is implemented by backends that make use of Request.Context
+type ContextTranslator interface {
+	Translator
+
+	SupportsContext() bool
+}
+
+// Factory creates a new Translator, it's called once for every worker in the pool
+type Factory func(opts Options) (Translator, error)
+
+var (
+	backendsMutex = &sync.Mutex{}
+	backends      = make(map[string]Factory)
+)
+
+// Register makes a backend available under the given name
+func Register(name string, factory Factory) {
+	backendsMutex.Lock()
+	defer backendsMutex.Unlock()
+
+	if factory == nil {
+		panic("translate: Register factory is nil")
+	}
+
+	if _, dup := backends[name]; dup {
+		panic("translate: Register called twice for backend " + name)
+	}
+
+	backends[name] = factory
+}
+
+// Backends returns a sorted list of names of registered backends
+func Backends() []string {
+	backendsMutex.Lock()
+	defer backendsMutex.Unlock()
+
+	var list []string
+	for name := range backends {
+		list = append(list, name)
+	}
+
+	sort.Strings(list)
+
+	return list
+}
+
+func getBackend(name string) (Factory, error) {
+	backendsMutex.Lock()
+	factory, ok := backends[name]
+	backendsMutex.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown translation backend %q (available: %v)", name, Backends())
+	}
+
+	return factory, nil
+}
@@ -0,0 +1,209 @@
+package translate
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"gitgud.io/softashell/rpgmaker-patch-translator/statictl"
+	"gitgud.io/softashell/rpgmaker-patch-translator/text"
+)
+
+const (
+	defaultMaxLengthRatio = 8
+	minRatioLength        = 20 // Short translations are never checked for length ratio
+	maxWordRepeats        = 4  // Same word repeated more than this in a row
+	maxPhraseRepeats      = 2  // Phrase of several words repeated more than this in a row
+)
+
+// Rule checks cleaned up translation of original text and returns error describing what's wrong with it
+type Rule func(original, translation string) error
+
+var (
+	rulesMutex = &sync.Mutex{}
+	rules      = make(map[string]Rule)
+
+	validators     []Rule
+	maxLengthRatio float64 = defaultMaxLengthRatio
+)
+
+func init() {
+	RegisterRule("echo", checkEcho)
+	RegisterRule("source", checkSourceScript)
+	RegisterRule("ratio", checkLengthRatio)
+	RegisterRule("repeat", checkRepetition)
+	RegisterRule("placeholder", checkPlaceholders)
+	RegisterRule("escape", checkEscapes)
+}
+
+// RegisterRule makes validation rule available under the given name
+func RegisterRule(name string, rule Rule) {
+	rulesMutex.Lock()
+	defer rulesMutex.Unlock()
+
+	if _, ok := rules[name]; ok {
+		panic(fmt.Sprintf("validation rule %q registered twice", name))
+	}
+
+	rules[name] = rule
+}
+
+// Rules returns sorted names of registered validation rules
+func Rules() []string {
+	rulesMutex.Lock()
+	defer rulesMutex.Unlock()
+
+	var names []string
+	for name := range rules {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// setValidators enables comma separated list of rules, empty list disables validation
+func setValidators(list string) error {
+	validators = nil
+
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if len(name) < 1 {
+			continue
+		}
+
+		rulesMutex.Lock()
+		rule, ok := rules[name]
+		rulesMutex.Unlock()
+
+		if !ok {
+			return fmt.Errorf("unknown validation rule %q, available rules are %v", name, Rules())
+		}
+
+		validators = append(validators, rule)
+	}
+
+	return nil
+}
+
+// validate runs every enabled rule and returns the first failure
+func validate(original, translation string) error {
+	for _, rule := range validators {
+		if err := rule(original, translation); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// invalidTranslation is returned when every attempt produced translation that failed validation
+type invalidTranslation struct {
+	translation string
+	reason      error
+}
+
+func (e *invalidTranslation) Error() string {
+	return fmt.Sprintf("translation failed validation: %v", e.reason)
+}
+
+func checkEcho(original, translation string) error {
+	if NormalizeCacheText(original) == NormalizeCacheText(translation) {
+		return fmt.Errorf("translation is the same as original")
+	}
+
+	return nil
+}
+
+func checkSourceScript(original, translation string) error {
+	if text.HasUntranslated(translation, toLanguage) {
+		return fmt.Errorf("translation contains untranslated text")
+	}
+
+	return nil
+}
+
+func checkLengthRatio(original, translation string) error {
+	n := utf8.RuneCountInString(translation)
+	if n < minRatioLength {
+		return nil
+	}
+
+	ratio := float64(n) / float64(utf8.RuneCountInString(original))
+	if ratio > maxLengthRatio {
+		return fmt.Errorf("translation is %.1f times longer than original", ratio)
+	}
+
+	return nil
+}
+
+func checkRepetition(original, translation string) error {
+	words := strings.FieldsFunc(strings.ToLower(translation), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '\''
+	})
+
+	for size := 1; size <= 4; size++ {
+		limit := maxPhraseRepeats
+		if size == 1 {
+			limit = maxWordRepeats
+		}
+
+		for start := 0; start+size <= len(words); start++ {
+			repeats := 1
+
+			for next := start + size; next+size <= len(words); next += size {
+				if !equalWords(words[start:start+size], words[next:next+size]) {
+					break
+				}
+
+				repeats++
+			}
+
+			if repeats > limit {
+				return fmt.Errorf("%q is repeated %d times", strings.Join(words[start:start+size], " "), repeats)
+			}
+		}
+	}
+
+	return nil
+}
+
+func equalWords(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func checkPlaceholders(original, translation string) error {
+	found := make(map[int]bool)
+	for _, i := range statictl.PlaceholderIndexes(translation) {
+		found[i] = true
+	}
+
+	for _, i := range statictl.PlaceholderIndexes(original) {
+		if !found[i] {
+			return fmt.Errorf("translation is missing glossary placeholder {T%d}", i)
+		}
+	}
+
+	return nil
+}
+
+var escapeRegex = regexp.MustCompile(`(?i)u00[0-9a-f]{2}`)
+
+func checkEscapes(original, translation string) error {
+	if m := escapeRegex.FindString(translation); len(m) > 0 {
+		return fmt.Errorf("translation contains escaped character %q", m)
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+package translate
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+)
+
+// Counts requests sent to test-echo backend
+var echoCalls int64
+
+func init() {
+	Register("test-echo", func(opts Options) (Translator, error) {
+		return &mockTranslator{fn: func(s string) string {
+			atomic.AddInt64(&echoCalls, 1)
+			return s
+		}}, nil
+	})
+}
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		rule        string
+		original    string
+		translation string
+		wantErr     bool
+	}{
+		{"echo", "こんにちは", "Hello", false},
+		{"echo", "こんにちは", " こんにちは", true},
+		{"source", "こんにちは", "Hello", false},
+		{"source", "勇者が来た", "The hero 来た", true},
+		{"ratio", "はい", "Yes", false},
+		{"ratio", "はい", "Yes, yes, I understand completely and absolutely", true},
+		{"repeat", "いやいや", "No no no", false},
+		{"repeat", "やめて", "Stop it, stop it, stop it, stop it", true},
+		{"repeat", "あああ", "Ah ah ah ah ah ah", true},
+		{"placeholder", "{T0}が{T1}を", "{T0} {t 1}", false},
+		{"placeholder", "{T0}が{T1}を", "{T1} and hero", true},
+		{"escape", "トムの", "Tom's", false},
+		{"escape", "トムの", "Tomu0027s", true},
+	}
+
+	for _, tt := range tests {
+		if err := setValidators(tt.rule); err != nil {
+			t.Fatal(err)
+		}
+
+		err := validate(tt.original, tt.translation)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: validate(%q, %q) error = %v, wantErr %v", tt.rule, tt.original, tt.translation, err, tt.wantErr)
+		}
+	}
+
+	if err := setValidators("echo,unknown"); err == nil {
+		t.Error("expected error for unknown rule")
+	}
+}
+
+func TestSourceScriptTarget(t *testing.T) {
+	defer func() { toLanguage = "en" }()
+
+	tests := []struct {
+		to          string
+		translation string
+		wantErr     bool
+	}{
+		{"ru", "Привет", false},
+		{"ru", "Привет さん", true},
+		{"ru", "Герой 来た", true},
+		{"zh", "勇者来了", false},
+		{"zh", "勇者来た", true},
+	}
+
+	for _, tt := range tests {
+		toLanguage = tt.to
+
+		err := checkSourceScript("勇者が来た", tt.translation)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: checkSourceScript(%q) error = %v, wantErr %v", tt.to, tt.translation, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidationRetry(t *testing.T) {
+	tests := []struct {
+		backends string
+		want     string
+		flagged  bool
+		calls    int64
+	}{
+		{"test-echo,reverse", "トステ", false, 2},
+		{"test-echo", "テスト", true, 2},
+	}
+
+	for _, tt := range tests {
+		// Echo returns the same text again so only one retry is made
+		err := Init(Options{Backend: tt.backends, Validate: "echo", ValidateRetries: 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		atomic.StoreInt64(&echoCalls, 0)
+		before := len(Flags())
+
+		got, err := String(context.Background(), "テスト", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.backends, got, tt.want)
+		}
+
+		if flagged := len(Flags()) > before; flagged != tt.flagged {
+			t.Errorf("%s: flagged = %v, want %v", tt.backends, flagged, tt.flagged)
+		}
+
+		if calls := atomic.LoadInt64(&echoCalls); calls != tt.calls {
+			t.Errorf("%s: test-echo was called %d times, want %d", tt.backends, calls, tt.calls)
+		}
+
+		Close()
+	}
+
+	setValidators("")
+}
@@ -1,46 +1,113 @@
 package translate
 
 import (
-	"net/rpc"
+	"context"
+	"fmt"
+	"time"
 
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
-type ComfyWorker struct {
-	client *rpc.Client
+const maxRetryDelay = time.Minute
+
+type workerResult struct {
+	response  Response
+	responses []Response // Set for batch requests
+	err       error
 }
 
-func newComfyWorker() ComfyWorker {
-	w := ComfyWorker{}
+// worker sends requests to a single translator and retries failed ones,
+// it's shared between concurrent requests so translator has to be safe for concurrent use
+type worker struct {
+	translator Translator
+	limiter    *rateLimiter
 
-	client, err := rpc.DialHTTP("tcp", "127.0.0.1:3000")
-	if err != nil {
-		log.Fatal("Failed to connect with translation service:", err)
-	}
+	retries    int
+	retryDelay time.Duration
+	timeout    time.Duration // Limit for a single attempt, 0 waits forever
+}
 
-	w.client = client
+func (w *worker) Process(ctx context.Context, payload interface{}) interface{} {
+	var result workerResult
 
-	return w
-}
+	switch req := payload.(type) {
+	case Request:
+		result.err = w.retry(ctx, func(ctx context.Context) error {
+			var err error
+			result.response, err = w.translator.Translate(ctx, req)
+			return err
+		})
+	case []Request:
+		bt, ok := w.translator.(BatchTranslator)
+		if !ok {
+			result.err = fmt.Errorf("translation backend doesn't support batch requests")
+			break
+		}
 
-func (w ComfyWorker) Process(payload interface{}) interface{} {
-	return comfyTranslate(w.client, payload.(translateRequest))
+		result.err = w.retry(ctx, func(ctx context.Context) error {
+			var err error
+			result.responses, err = bt.TranslateBatch(ctx, req)
+			return err
+		})
+	default:
+		result.err = fmt.Errorf("unexpected payload %T", payload)
+	}
+
+	return result
 }
 
-// BlockUntilReady is called before each job is processed and must block the
-// calling goroutine until the Worker is ready to process the next job.
-func (w ComfyWorker) BlockUntilReady() {
+// retry calls fn until it succeeds, retry budget runs out or ctx is done
+func (w *worker) retry(ctx context.Context, fn func(ctx context.Context) error) error {
+	delay := w.retryDelay
 
-}
+	var err error
+
+	for attempt := 0; attempt <= w.retries; attempt++ {
+		if attempt > 0 {
+			log.Warnf("Translation failed, retrying in %s (%d/%d): %v", delay, attempt, w.retries, err)
+
+			if err := sleep(ctx, delay); err != nil {
+				return err
+			}
 
-// Interrupt is called when a job is cancelled. The worker is responsible
-// for unblocking the Process implementation.
-func (w ComfyWorker) Interrupt() {
+			delay *= 2
+			if delay > maxRetryDelay {
+				delay = maxRetryDelay
+			}
+		}
 
+		if err := w.limiter.Wait(ctx); err != nil {
+			return err
+		}
+
+		err = w.attempt(ctx, fn)
+		if err == nil {
+			break
+		}
+
+		// Nothing to retry if the whole request was cancelled
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	return err
 }
 
-// Terminate is called when a Worker is removed from the processing pool
-// and is responsible for cleaning up any held resources.
-func (w ComfyWorker) Terminate() {
-	w.client.Close()
+// attempt calls fn with a context that expires after worker timeout
+func (w *worker) attempt(ctx context.Context, fn func(ctx context.Context) error) error {
+	if w.timeout <= 0 {
+		return fn(ctx)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+
+	err := fn(ctx)
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return errors.Wrapf(err, "translation request timed out after %s", w.timeout)
+	}
+
+	return err
 }
@@ -0,0 +1,109 @@
+package translate
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+type flakyTranslator struct {
+	failures int
+	calls    int
+}
+
+func (t *flakyTranslator) Translate(ctx context.Context, req Request) (Response, error) {
+	t.calls++
+
+	if t.calls <= t.failures {
+		return Response{}, fmt.Errorf("connection refused")
+	}
+
+	return Response{TranslationText: req.Text}, nil
+}
+
+func (t *flakyTranslator) Close() error {
+	return nil
+}
+
+// hangingTranslator never replies, it only returns once ctx is done
+type hangingTranslator struct {
+	calls int
+}
+
+func (t *hangingTranslator) Translate(ctx context.Context, req Request) (Response, error) {
+	t.calls++
+
+	<-ctx.Done()
+
+	return Response{}, ctx.Err()
+}
+
+func (t *hangingTranslator) Close() error {
+	return nil
+}
+
+func TestWorkerRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int
+		retries   int
+		wantCalls int
+		wantErr   bool
+	}{
+		{"no failures", 0, 3, 1, false},
+		{"recovers", 2, 3, 3, false},
+		{"budget exhausted", 5, 3, 4, true},
+		{"no retries", 1, 0, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := &flakyTranslator{failures: tt.failures}
+			w := &worker{translator: tr, retries: tt.retries}
+
+			result := w.Process(context.Background(), Request{Text: "test"}).(workerResult)
+
+			if (result.err != nil) != tt.wantErr {
+				t.Errorf("Process() error = %v, wantErr %v", result.err, tt.wantErr)
+			}
+
+			if tr.calls != tt.wantCalls {
+				t.Errorf("Process() made %d calls, want %d", tr.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestWorkerTimeout(t *testing.T) {
+	tr := &hangingTranslator{}
+	w := &worker{translator: tr, retries: 2, timeout: 10 * time.Millisecond}
+
+	result := w.Process(context.Background(), Request{Text: "test"}).(workerResult)
+	if result.err == nil {
+		t.Error("expected timeout error")
+	}
+
+	if tr.calls != 3 {
+		t.Errorf("Process() made %d calls, want 3", tr.calls)
+	}
+}
+
+func TestWorkerCancel(t *testing.T) {
+	tr := &flakyTranslator{failures: 1}
+	w := &worker{translator: tr, retries: 3, retryDelay: time.Hour}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+
+	result := w.Process(ctx, Request{Text: "test"}).(workerResult)
+	if result.err != context.DeadlineExceeded {
+		t.Errorf("Process() error = %v, want %v", result.err, context.DeadlineExceeded)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("cancelled request kept waiting for %s", elapsed)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"gitgud.io/softashell/rpgmaker-patch-translator/engine"
+	"gitgud.io/softashell/rpgmaker-patch-translator/patch"
+	log "github.com/sirupsen/logrus"
+)
+
+// logDiagnostics reports problems found in patch file while translating it
+func logDiagnostics(diagnostics []patch.Diagnostic) {
+	for _, d := range diagnostics {
+		if d.Severity == patch.Error {
+			log.Error(d)
+		} else {
+			log.Warn(d)
+		}
+	}
+}
+
+// validatePatch checks version header and every patch file, or data file of MV/MZ game
+func validatePatch(dir string) ([]patch.Diagnostic, int) {
+	var diagnostics []patch.Diagnostic
+
+	e, err := detectEngine(dir)
+	if err != nil {
+		diagnostics = append(diagnostics, patch.Diagnostic{Path: dir, Severity: patch.Error, Message: err.Error()})
+	}
+
+	engine.Set(e)
+
+	files := sourceFiles(dir)
+	if len(files) < 1 {
+		diagnostics = append(diagnostics, patch.Diagnostic{Path: dir, Severity: patch.Error, Message: "no patch files found"})
+	}
+
+	var version string
+
+	for _, file := range files {
+		pf, err := readSource(file)
+
+		diagnostics = append(diagnostics, pf.Diagnostics...)
+
+		if err != nil {
+			diagnostics = append(diagnostics, patch.Diagnostic{Path: file, Severity: patch.Error, Message: err.Error()})
+			continue
+		}
+
+		if msg := checkFileVersion(e, pf.Version); len(msg) > 0 {
+			diagnostics = append(diagnostics, patch.Diagnostic{Path: file, Line: 1, Severity: patch.Error, Message: msg})
+		}
+
+		if len(version) < 1 {
+			version = pf.Version
+		} else if pf.Version != version {
+			diagnostics = append(diagnostics, patch.Diagnostic{
+				Path:     file,
+				Line:     1,
+				Severity: patch.Warning,
+				Message:  fmt.Sprintf("version %q differs from %q used by other files", pf.Version, version),
+			})
+		}
+	}
+
+	return diagnostics, len(files)
+}
+
+// checkFileVersion returns what's wrong with version of a single file for detected engine
+func checkFileVersion(e engine.EngineType, version string) string {
+	switch e {
+	case engine.RPGMVX:
+		if !strings.HasPrefix(version, "RPGMAKER TRANS PATCH FILE VERSION") {
+			return fmt.Sprintf("version %q isn't an RPG Maker patch", version)
+		}
+	case engine.Wolf:
+		if !strings.HasPrefix(version, "WOLF TRANS PATCH FILE VERSION") {
+			return fmt.Sprintf("version %q isn't a WOLF RPG patch", version)
+		}
+	}
+
+	return ""
+}
+
+// validateCommand checks the whole patch and fails if there are errors, warnings only fail in strict mode
+func validateCommand(args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+
+	var strict bool
+
+	fs.BoolVar(&strict, "strict", false, "Treat warnings as errors")
+
+	fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		return fmt.Errorf("validate command requires patch directory as argument")
+	}
+
+	diagnostics, files := validatePatch(fs.Arg(0))
+
+	var errs, warnings int
+
+	for _, d := range diagnostics {
+		if d.Severity == patch.Error {
+			errs++
+		} else {
+			warnings++
+		}
+
+		fmt.Println(d)
+	}
+
+	fmt.Printf("Checked %d files: %d errors, %d warnings\n", files, errs, warnings)
+
+	if errs > 0 || (strict && warnings > 0) {
+		return fmt.Errorf("patch has problems")
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitgud.io/softashell/rpgmaker-patch-translator/patch"
+)
+
+func TestValidatePatch(t *testing.T) {
+	diagnostics, files := validatePatch(filepath.Join("testdata", "e2e"))
+	if files != 1 || len(diagnostics) > 0 {
+		t.Errorf("valid patch: %d files, diagnostics %v", files, diagnostics)
+	}
+
+	dir, err := ioutil.TempDir("", "validate")
+	check(err)
+	defer os.RemoveAll(dir)
+
+	err = os.MkdirAll(filepath.Join(dir, "Patch"), 0755)
+	check(err)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "RPGMKTRANSPATCH"), []byte("> RPGMAKER TRANS PATCH V3\n"), 0644)
+	check(err)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "Patch", "Wolf.txt"), []byte("> WOLF TRANS PATCH FILE VERSION 1.0\n"), 0644)
+	check(err)
+
+	diagnostics, _ = validatePatch(dir)
+	if !patch.HasErrors(diagnostics) {
+		t.Errorf("expected error for WOLF file in RPG Maker patch, got %v", diagnostics)
+	}
+
+	// Missing directory is reported instead of crashing
+	diagnostics, files = validatePatch(filepath.Join(dir, "missing"))
+	if files != 0 || !patch.HasErrors(diagnostics) {
+		t.Errorf("missing directory: %d files, diagnostics %v", files, diagnostics)
+	}
+}
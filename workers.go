@@ -1,20 +1,29 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"gitgud.io/softashell/rpgmaker-patch-translator/block"
+	"gitgud.io/softashell/rpgmaker-patch-translator/patch"
+	"gitgud.io/softashell/rpgmaker-patch-translator/translate"
 	"github.com/vbauerster/mpb"
 	"github.com/vbauerster/mpb/decor"
 )
 
+// Blocks that were left untranslated because translation service failed
+var failedBlocks int64
+
 type blockWork struct {
 	id    int // Only needed to preserve order in patch file
-	block block.PatchBlock
+	block patch.PatchBlock
 }
 
-func createFileWorkers(fileCount int) (chan string, chan error) {
+// createFileWorkers starts file workers, see processFile for how ctx and stop are used
+func createFileWorkers(ctx, stop context.Context, fileCount int) (chan string, chan error) {
 	workerCount := cFileThreads
 
 	if workerCount < 1 {
@@ -27,7 +36,8 @@ func createFileWorkers(fileCount int) (chan string, chan error) {
 	results := make(chan error, workerCount)
 
 	p := mpb.New(
-		mpb.WithRefreshRate(100 * time.Millisecond),
+		mpb.WithRefreshRate(100*time.Millisecond),
+		mpb.WithCancel(ctx.Done()),
 	)
 
 	bar := p.AddBar(int64(fileCount),
@@ -35,6 +45,9 @@ func createFileWorkers(fileCount int) (chan string, chan error) {
 			decor.Name("Overall progress", decor.WC{W: 25, C: decor.DSyncSpace}),
 			decor.CountersNoUnit("%d / %d", decor.WC{C: decor.DSyncSpace}),
 		),
+		mpb.AppendDecorators(
+			newQueueDecorator(decor.WC{C: decor.DSyncSpace}),
+		),
 	)
 
 	lock := sync.Mutex{}
@@ -43,7 +56,7 @@ func createFileWorkers(fileCount int) (chan string, chan error) {
 	for w := 1; w <= workerCount; w++ {
 		go func(jobs <-chan string, results chan<- error) {
 			for j := range jobs {
-				results <- processFile(p, j)
+				results <- processFile(ctx, stop, p, j)
 				bar.Increment()
 			}
 
@@ -53,6 +66,12 @@ func createFileWorkers(fileCount int) (chan string, chan error) {
 			workerCount--
 			if workerCount < 1 {
 				close(results)
+
+				// Files that were never started don't count towards progress
+				if !bar.Completed() {
+					p.Abort(bar, false)
+				}
+
 				p.Wait()
 			}
 		}(jobs, results)
@@ -61,7 +80,24 @@ func createFileWorkers(fileCount int) (chan string, chan error) {
 	return jobs, results
 }
 
-func createBlockWorkers(blockCount int) (chan blockWork, chan blockWork) {
+// queueDecorator shows how many translation requests are waiting
+type queueDecorator struct {
+	decor.WC
+}
+
+func newQueueDecorator(wc decor.WC) decor.Decorator {
+	wc.Init()
+
+	return &queueDecorator{wc}
+}
+
+func (d *queueDecorator) Decor(st *decor.Statistics) string {
+	queued, inFlight := translate.QueueLength()
+
+	return d.FormatMsg(fmt.Sprintf("queued: %d in flight: %d", queued, inFlight))
+}
+
+func createBlockWorkers(ctx context.Context, blockCount int) (chan blockWork, chan blockWork) {
 	workerCount := cBlockThreads
 
 	if workerCount < 1 {
@@ -78,7 +114,14 @@ func createBlockWorkers(blockCount int) (chan blockWork, chan blockWork) {
 	for w := 1; w <= workerCount; w++ {
 		go func(jobs <-chan blockWork, results chan<- blockWork) {
 			for j := range jobs {
-				j.block = block.ParseBlock(j.block)
+				var err error
+
+				j.block, err = block.ParseBlock(ctx, j.block)
+				if err != nil && ctx.Err() == nil {
+					atomic.AddInt64(&failedBlocks, 1)
+					logBlockError(err, j.block)
+				}
+
 				results <- j
 			}
 